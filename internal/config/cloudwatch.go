@@ -0,0 +1,53 @@
+package config
+
+// CloudWatchConfig holds CloudWatch Logs preferences
+type CloudWatchConfig struct {
+	SavedQueries map[string][]SavedQuery `json:"saved_queries,omitempty"` // Logs Insights queries by log group
+}
+
+// SavedQuery is a named Logs Insights query
+type SavedQuery struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// GetSavedQueries returns the queries saved for a log group
+func (c *Config) GetSavedQueries(logGroup string) []SavedQuery {
+	if queries, ok := c.CloudWatch.SavedQueries[logGroup]; ok {
+		return queries
+	}
+	return nil
+}
+
+// SaveQuery adds a query to a log group's library, replacing one with the same name
+func (c *Config) SaveQuery(logGroup string, query SavedQuery) {
+	if c.CloudWatch.SavedQueries == nil {
+		c.CloudWatch.SavedQueries = make(map[string][]SavedQuery)
+	}
+
+	queries := c.CloudWatch.SavedQueries[logGroup]
+	for i, q := range queries {
+		if q.Name == query.Name {
+			queries[i] = query
+			return
+		}
+	}
+	c.CloudWatch.SavedQueries[logGroup] = append(queries, query)
+}
+
+// DeleteSavedQuery removes a named query from a log group's library
+func (c *Config) DeleteSavedQuery(logGroup string, name string) {
+	queries := c.CloudWatch.SavedQueries[logGroup]
+	for i, q := range queries {
+		if q.Name == name {
+			queries = append(queries[:i:i], queries[i+1:]...)
+			break
+		}
+	}
+
+	if len(queries) == 0 {
+		delete(c.CloudWatch.SavedQueries, logGroup)
+	} else {
+		c.CloudWatch.SavedQueries[logGroup] = queries
+	}
+}
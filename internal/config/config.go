@@ -9,14 +9,34 @@ import (
 )
 
 type Config struct {
-	DynamoDB DynamoDBConfig `json:"dynamodb"`
+	DynamoDB   DynamoDBConfig   `json:"dynamodb"`
+	CloudWatch CloudWatchConfig `json:"cloudwatch"`
+	Discovery  DiscoveryConfig  `json:"discovery"`
 }
 
 type DynamoDBConfig struct {
 	TableColumnPreferences     map[string][]string                 `json:"table_column_preferences"`
 	FilterConditionPreferences map[string][]filter.FilterCondition `json:"filter_condition_preferences"`
+	PartiQLHistory             map[string][]string                 `json:"partiql_history,omitempty"`
+	BatchWrite                 BatchWriteConfig                    `json:"batch_write"`
 }
 
+// BatchWriteConfig tunes bulk BatchWriteItem runs such as emptying a table
+type BatchWriteConfig struct {
+	Workers       int `json:"workers,omitempty"`        // Concurrent BatchWriteItem calls
+	WriteCapacity int `json:"write_capacity,omitempty"` // Items written per second across all workers, 0 for no limit
+	MaxRetries    int `json:"max_retries,omitempty"`    // Retries per batch for throttling and unprocessed items
+}
+
+// Maximum number of PartiQL statements remembered per table
+const maxPartiQLHistory = 50
+
+// Batch write defaults used when the config leaves them unset
+const (
+	defaultBatchWriteWorkers    = 4
+	defaultBatchWriteMaxRetries = 8
+)
+
 func NewConfig() *Config {
 	return &Config{
 		DynamoDB: DynamoDBConfig{
@@ -25,18 +45,43 @@ func NewConfig() *Config {
 	}
 }
 
-func (c *Config) GetConfigPath() (string, error) {
+// configDir returns ~/.aws-tui, creating it if needed
+func configDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
 
-	configDir := filepath.Join(homeDir, ".aws-tui")
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	dir := filepath.Join(homeDir, ".aws-tui")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+func (c *Config) GetConfigPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
 		return "", err
 	}
 
-	return filepath.Join(configDir, "config.json"), nil
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// GetBackupDir returns the directory holding a table's backups, creating it if needed
+func (c *Config) GetBackupDir(tableName string) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	backupDir := filepath.Join(dir, "backups", tableName)
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return "", err
+	}
+
+	return backupDir, nil
 }
 
 func (c *Config) Save() error {
@@ -107,3 +152,45 @@ func (c *Config) GetFilterConditions(tableName string) []filter.FilterCondition
 	}
 	return nil
 }
+
+// AddPartiQLStatement records a statement as the most recent one for the table
+func (c *Config) AddPartiQLStatement(tableName string, statement string) {
+	if c.DynamoDB.PartiQLHistory == nil {
+		c.DynamoDB.PartiQLHistory = make(map[string][]string)
+	}
+
+	history := []string{statement}
+	for _, s := range c.DynamoDB.PartiQLHistory[tableName] {
+		if s != statement {
+			history = append(history, s)
+		}
+	}
+	if len(history) > maxPartiQLHistory {
+		history = history[:maxPartiQLHistory]
+	}
+
+	c.DynamoDB.PartiQLHistory[tableName] = history
+}
+
+// GetPartiQLHistory returns the table's statements, most recent first
+func (c *Config) GetPartiQLHistory(tableName string) []string {
+	if history, ok := c.DynamoDB.PartiQLHistory[tableName]; ok {
+		return history
+	}
+	return nil
+}
+
+// GetBatchWriteConfig returns the batch write settings with defaults filled in
+func (c *Config) GetBatchWriteConfig() BatchWriteConfig {
+	cfg := c.DynamoDB.BatchWrite
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultBatchWriteWorkers
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = defaultBatchWriteMaxRetries
+	}
+	if cfg.WriteCapacity < 0 {
+		cfg.WriteCapacity = 0
+	}
+	return cfg
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DiscoveryModeAll lists every table or log group instead of matching name patterns
+const DiscoveryModeAll = "all"
+
+// envPlaceholder in a pattern is replaced by the -env flag
+const envPlaceholder = "{env}"
+
+// Patterns starting with regexPrefix are regular expressions, all others are globs
+const regexPrefix = "re:"
+
+// DiscoveryConfig decides which tables and log groups are listed. The rules of
+// an environment in Envs replace the top-level ones for that environment.
+type DiscoveryConfig struct {
+	Tables    *DiscoveryRules               `json:"tables,omitempty"`
+	LogGroups *DiscoveryRules               `json:"log_groups,omitempty"`
+	Envs      map[string]EnvDiscoveryConfig `json:"envs,omitempty"`
+}
+
+// EnvDiscoveryConfig holds the rules of a single environment
+type EnvDiscoveryConfig struct {
+	Tables    *DiscoveryRules `json:"tables,omitempty"`
+	LogGroups *DiscoveryRules `json:"log_groups,omitempty"`
+}
+
+// DiscoveryRules select resources by name and tags. Patterns are globs such as
+// "dev-cot*{env}" or, prefixed with "re:", regular expressions; both must match
+// the whole name, and {env} stands for the environment. A name is kept if it matches an include pattern
+// (or there are none) and no exclude pattern.
+type DiscoveryRules struct {
+	Mode    string            `json:"mode,omitempty"` // Empty for patterns, or "all"
+	Include []string          `json:"include,omitempty"`
+	Exclude []string          `json:"exclude,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"` // Every tag must be present; an empty value accepts any value
+}
+
+// The rules used when the config has none keep the original dev-cot naming convention
+var (
+	defaultTableDiscovery    = DiscoveryRules{Include: []string{"dev-cot*" + envPlaceholder}}
+	defaultLogGroupDiscovery = DiscoveryRules{Include: []string{"/aws/lambda/dev-cot*-" + envPlaceholder}}
+)
+
+// GetTableDiscovery returns the table rules of an environment
+func (c *Config) GetTableDiscovery(env string) DiscoveryRules {
+	if rules := c.Discovery.Envs[env].Tables; rules != nil {
+		return *rules
+	}
+	if c.Discovery.Tables != nil {
+		return *c.Discovery.Tables
+	}
+	return defaultTableDiscovery
+}
+
+// GetLogGroupDiscovery returns the log group rules of an environment
+func (c *Config) GetLogGroupDiscovery(env string) DiscoveryRules {
+	if rules := c.Discovery.Envs[env].LogGroups; rules != nil {
+		return *rules
+	}
+	if c.Discovery.LogGroups != nil {
+		return *c.Discovery.LogGroups
+	}
+	return defaultLogGroupDiscovery
+}
+
+// GetAllEnvTableMatchers returns matchers for the tables of every environment:
+// each configured environment's own rules, plus the shared rules with {env}
+// matching any environment
+func (c *Config) GetAllEnvTableMatchers() ([]*NameMatcher, error) {
+	var matchers []*NameMatcher
+	for env, envRules := range c.Discovery.Envs {
+		if envRules.Tables == nil {
+			continue
+		}
+		matcher, err := envRules.Tables.Compile(env)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	shared := defaultTableDiscovery
+	if c.Discovery.Tables != nil {
+		shared = *c.Discovery.Tables
+	}
+	matcher, err := shared.Compile("")
+	if err != nil {
+		return nil, err
+	}
+	return append(matchers, matcher), nil
+}
+
+// NameMatcher is a compiled set of discovery rules
+type NameMatcher struct {
+	all     bool
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	prefix  string // Literal prefix every included name starts with
+	Tags    map[string]string
+}
+
+// Compile resolves {env} and compiles the patterns. An empty env matches any environment.
+func (r DiscoveryRules) Compile(env string) (*NameMatcher, error) {
+	m := &NameMatcher{all: r.Mode == DiscoveryModeAll, Tags: r.Tags}
+	switch r.Mode {
+	case "", "patterns", DiscoveryModeAll:
+	default:
+		return nil, fmt.Errorf("unknown discovery mode %q", r.Mode)
+	}
+
+	var err error
+	if m.include, err = compilePatterns(r.Include, env); err != nil {
+		return nil, err
+	}
+	if m.exclude, err = compilePatterns(r.Exclude, env); err != nil {
+		return nil, err
+	}
+
+	if !m.all {
+		m.prefix = commonLiteralPrefix(r.Include, env)
+	}
+	return m, nil
+}
+
+// Match reports whether a name passes the rules' patterns. Tags are checked separately.
+func (m *NameMatcher) Match(name string) bool {
+	if m.all {
+		return true
+	}
+
+	included := len(m.include) == 0
+	for _, re := range m.include {
+		if re.MatchString(name) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+
+	for _, re := range m.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchTags reports whether a resource carries every required tag
+func (m *NameMatcher) MatchTags(tags map[string]string) bool {
+	for key, want := range m.Tags {
+		got, ok := tags[key]
+		if !ok || (want != "" && got != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Prefix returns a literal prefix of every matching name, for narrowing list calls server-side
+func (m *NameMatcher) Prefix() string {
+	return m.prefix
+}
+
+func compilePatterns(patterns []string, env string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		expr := patternRegexp(pattern, env)
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid discovery pattern %q: %w", pattern, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// patternRegexp turns a glob or re: pattern into an anchored regular expression
+func patternRegexp(pattern, env string) string {
+	if expr, ok := strings.CutPrefix(pattern, regexPrefix); ok {
+		envExpr := ".*"
+		if env != "" {
+			envExpr = regexp.QuoteMeta(env)
+		}
+		return "^(?:" + strings.ReplaceAll(expr, envPlaceholder, envExpr) + ")$"
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i, part := range strings.Split(pattern, envPlaceholder) {
+		if i > 0 {
+			if env == "" {
+				b.WriteString(".*")
+			} else {
+				b.WriteString(regexp.QuoteMeta(env))
+			}
+		}
+		for _, r := range part {
+			switch r {
+			case '*':
+				b.WriteString(".*")
+			case '?':
+				b.WriteString(".")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// commonLiteralPrefix returns the longest literal start shared by all glob
+// include patterns, or "" if there are none or any is a regular expression
+func commonLiteralPrefix(patterns []string, env string) string {
+	if len(patterns) == 0 {
+		return ""
+	}
+
+	var prefix string
+	for i, pattern := range patterns {
+		if strings.HasPrefix(pattern, regexPrefix) {
+			return ""
+		}
+		if env != "" {
+			pattern = strings.ReplaceAll(pattern, envPlaceholder, env)
+		}
+		literal := pattern
+		if cut := strings.IndexAny(pattern, "*?{"); cut >= 0 {
+			literal = pattern[:cut]
+		}
+
+		if i == 0 {
+			prefix = literal
+			continue
+		}
+		for !strings.HasPrefix(literal, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultTableDiscovery(t *testing.T) {
+	names := []string{
+		"dev-cot-orders-prod",
+		"dev-cot-orders-staging",
+		"dev-cotprod",
+		"dev-cot-prod-archive",
+		"dev-co-orders-prod",
+		"prod-dev-cot-orders-prod",
+		"other-table",
+		"dev-cot-",
+	}
+
+	for _, env := range []string{"prod", "staging", ""} {
+		matcher, err := defaultTableDiscovery.Compile(env)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", env, err)
+		}
+		for _, name := range names {
+			// The rule before discovery was configurable
+			want := strings.HasPrefix(name, "dev-cot") && strings.HasSuffix(name, env)
+			if got := matcher.Match(name); got != want {
+				t.Errorf("env %q: Match(%q) = %v, want %v", env, name, got, want)
+			}
+		}
+	}
+}
+
+func TestNameMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   DiscoveryRules
+		env     string
+		match   []string
+		noMatch []string
+	}{
+		{
+			name:    "no include patterns keeps everything not excluded",
+			rules:   DiscoveryRules{Exclude: []string{"*-archive"}},
+			match:   []string{"orders", "x"},
+			noMatch: []string{"orders-archive"},
+		},
+		{
+			name:    "glob wildcards",
+			rules:   DiscoveryRules{Include: []string{"app-?-*"}},
+			match:   []string{"app-a-orders", "app-b-"},
+			noMatch: []string{"app-ab-orders", "my-app-a-orders"},
+		},
+		{
+			name:    "glob metacharacters are literal",
+			rules:   DiscoveryRules{Include: []string{"a.b+(c)*"}},
+			match:   []string{"a.b+(c)", "a.b+(c)-x"},
+			noMatch: []string{"axb+(c)", "a.bb(c)"},
+		},
+		{
+			name:    "env placeholder",
+			rules:   DiscoveryRules{Include: []string{"svc-{env}-*"}},
+			env:     "prod",
+			match:   []string{"svc-prod-orders"},
+			noMatch: []string{"svc-staging-orders", "svc-prod"},
+		},
+		{
+			name:    "empty env matches any environment",
+			rules:   DiscoveryRules{Include: []string{"svc-{env}-*", "re:logs-{env}"}},
+			match:   []string{"svc-prod-orders", "svc--orders", "logs-staging", "logs-"},
+			noMatch: []string{"svc-prod", "other-prod-orders"},
+		},
+		{
+			name:    "env is quoted in regular expressions",
+			rules:   DiscoveryRules{Include: []string{"re:svc-{env}"}},
+			env:     "a.b",
+			match:   []string{"svc-a.b"},
+			noMatch: []string{"svc-axb"},
+		},
+		{
+			name:    "regular expressions are anchored",
+			rules:   DiscoveryRules{Include: []string{"re:orders|payments"}},
+			match:   []string{"orders", "payments"},
+			noMatch: []string{"old-orders-archive", "orders-x", "x-payments"},
+		},
+		{
+			name: "exclude wins over include",
+			rules: DiscoveryRules{
+				Include: []string{"dev-*", "re:.*-orders"},
+				Exclude: []string{"*-tmp", "re:dev-(old|legacy)-.*"},
+			},
+			match:   []string{"dev-orders", "prod-orders"},
+			noMatch: []string{"dev-orders-tmp", "dev-old-orders", "dev-legacy-x", "prod"},
+		},
+		{
+			name:    "mode all ignores patterns",
+			rules:   DiscoveryRules{Mode: DiscoveryModeAll, Include: []string{"x"}, Exclude: []string{"*"}},
+			match:   []string{"anything", ""},
+			noMatch: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := tt.rules.Compile(tt.env)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			for _, name := range tt.match {
+				if !matcher.Match(name) {
+					t.Errorf("Match(%q) = false, want true", name)
+				}
+			}
+			for _, name := range tt.noMatch {
+				if matcher.Match(name) {
+					t.Errorf("Match(%q) = true, want false", name)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, rules := range []DiscoveryRules{
+		{Mode: "some"},
+		{Include: []string{"re:("}},
+		{Exclude: []string{"re:[a-"}},
+	} {
+		if _, err := rules.Compile("prod"); err == nil {
+			t.Errorf("Compile(%+v) succeeded, want an error", rules)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		include []string
+		env     string
+		want    string
+	}{
+		{name: "no patterns", want: ""},
+		{name: "single glob", include: []string{"dev-cot*{env}"}, env: "prod", want: "dev-cot"},
+		{name: "env is part of the literal", include: []string{"{env}-orders*"}, env: "prod", want: "prod-orders"},
+		{name: "empty env stops the literal", include: []string{"svc-{env}-orders"}, want: "svc-"},
+		{name: "fully literal pattern", include: []string{"orders"}, want: "orders"},
+		{name: "shared start of several globs", include: []string{"app-orders-*", "app-order?", "app-payments"}, want: "app-"},
+		{name: "nothing shared", include: []string{"orders*", "payments*"}, want: ""},
+		{name: "a regular expression disables the prefix", include: []string{"app-*", "re:app-.*"}, want: ""},
+		{name: "a regular expression first", include: []string{"re:app-.*", "app-*"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := DiscoveryRules{Include: tt.include}.Compile(tt.env)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if got := matcher.Prefix(); got != tt.want {
+				t.Errorf("Prefix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	all, err := DiscoveryRules{Mode: DiscoveryModeAll, Include: []string{"app-*"}}.Compile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := all.Prefix(); got != "" {
+		t.Errorf("Prefix() in mode all = %q, want empty", got)
+	}
+}
+
+func TestGetAllEnvTableMatchers(t *testing.T) {
+	cfg := &Config{Discovery: DiscoveryConfig{
+		Tables: &DiscoveryRules{Include: []string{"shared-*-{env}"}},
+		Envs: map[string]EnvDiscoveryConfig{
+			"prod":    {Tables: &DiscoveryRules{Include: []string{"prod-*"}, Exclude: []string{"*-tmp"}}},
+			"staging": {LogGroups: &DiscoveryRules{Include: []string{"x"}}},
+		},
+	}}
+
+	matchers, err := cfg.GetAllEnvTableMatchers()
+	if err != nil {
+		t.Fatalf("GetAllEnvTableMatchers failed: %v", err)
+	}
+	// The prod rules and the shared rules; staging has no table rules of its own
+	if len(matchers) != 2 {
+		t.Fatalf("got %d matchers, want 2", len(matchers))
+	}
+
+	matchAny := func(name string) bool {
+		for _, m := range matchers {
+			if m.Match(name) {
+				return true
+			}
+		}
+		return false
+	}
+	for name, want := range map[string]bool{
+		"prod-orders":        true,
+		"prod-orders-tmp":    false,
+		"shared-orders-prod": true,
+		"shared-orders-qa":   true,
+		"staging-orders":     false,
+		"x":                  false,
+	} {
+		if got := matchAny(name); got != want {
+			t.Errorf("%q matched = %v, want %v", name, got, want)
+		}
+	}
+
+	// Without any configuration the default rule applies to every environment
+	matchers, err = (&Config{}).GetAllEnvTableMatchers()
+	if err != nil {
+		t.Fatalf("GetAllEnvTableMatchers failed: %v", err)
+	}
+	if len(matchers) != 1 || !matchers[0].Match("dev-cot-orders-qa") || matchers[0].Match("orders") {
+		t.Errorf("default matchers don't follow the dev-cot rule")
+	}
+}
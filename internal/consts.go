@@ -1,4 +0,0 @@
-package internal
-
-const AWSTagKey = "Monitor"
-const AWSTagValue = "cot_dev"
@@ -1,11 +1,8 @@
 package cloudwatch
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"os/exec"
-	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -20,59 +17,59 @@ type logGroupsLoadedMsg struct {
 }
 
 type logEventsLoadedMsg struct {
-	events []types.FilteredLogEvent
-	err    error
+	events    []types.FilteredLogEvent
+	truncated bool
+	until     int64  // End of the loaded range in epoch milliseconds
+	pattern   string // CloudWatch filter pattern the events match
+	err       error
 }
 
-type filteredLogsMsg struct {
-	output string
-	err    error
-}
+// maxLogEvents bounds how many events a long range loads
+const maxLogEvents = 10000
 
-func (m Model) filterWithRipgrep(pattern string) tea.Cmd {
+// loadLogGroups lists the log groups picked by the environment's discovery rules
+func (m Model) loadLogGroups() tea.Cmd {
 	return func() tea.Msg {
-		// Check if ripgrep is available
-		cmd := exec.Command("rg", "--color", "never", pattern)
-		cmd.Stdin = strings.NewReader(m.allLogsText)
-
-		var out bytes.Buffer
-		cmd.Stdout = &out
-
-		if err := cmd.Run(); err != nil {
-			// If ripgrep not found or no matches, return error
-			if _, ok := err.(*exec.ExitError); ok {
-				// Exit code 1 means no matches (not an error)
-				return filteredLogsMsg{output: "No matches found", err: nil}
-			}
-			return filteredLogsMsg{err: err}
+		matcher, err := m.config.GetLogGroupDiscovery(m.env).Compile(m.env)
+		if err != nil {
+			return logGroupsLoadedMsg{err: err}
 		}
 
-		return filteredLogsMsg{output: out.String(), err: nil}
-	}
-}
-
-func (m Model) loadLambdaLogGroups() tea.Cmd {
-	return func() tea.Msg {
 		var filteredGroups []types.LogGroup
 		var nextToken *string
 
 		for {
-			// Still use Lambda prefix to reduce API calls
-			result, err := m.client.DescribeLogGroups(
-				context.TODO(),
-				&cloudwatchlogs.DescribeLogGroupsInput{
-					LogGroupNamePattern: aws.String(
-						fmt.Sprintf("/aws/lambda/dev-cot*-%s", m.env),
-					),
-					Limit:     aws.Int32(50),
-					NextToken: nextToken,
-				},
-			)
+			input := &cloudwatchlogs.DescribeLogGroupsInput{
+				Limit:     aws.Int32(50),
+				NextToken: nextToken,
+			}
+			// Narrow the listing server-side when every pattern shares a prefix
+			if prefix := matcher.Prefix(); prefix != "" {
+				input.LogGroupNamePrefix = aws.String(prefix)
+			}
+
+			result, err := m.client.DescribeLogGroups(context.TODO(), input)
 			if err != nil {
 				return logGroupsLoadedMsg{err: err}
 			}
 
-			filteredGroups = append(filteredGroups, result.LogGroups...)
+			for _, group := range result.LogGroups {
+				if !matcher.Match(aws.ToString(group.LogGroupName)) {
+					continue
+				}
+				if len(matcher.Tags) > 0 {
+					tags, err := m.client.ListTagsForResource(context.TODO(), &cloudwatchlogs.ListTagsForResourceInput{
+						ResourceArn: group.LogGroupArn,
+					})
+					if err != nil {
+						return logGroupsLoadedMsg{err: fmt.Errorf("failed to read tags of %s: %w", aws.ToString(group.LogGroupName), err)}
+					}
+					if !matcher.MatchTags(tags.Tags) {
+						continue
+					}
+				}
+				filteredGroups = append(filteredGroups, group)
+			}
 
 			if result.NextToken == nil {
 				break
@@ -84,11 +81,16 @@ func (m Model) loadLambdaLogGroups() tea.Cmd {
 	}
 }
 
+// loadLogEvents reads a log group's events in the selected time range, oldest first
 func (m Model) loadLogEvents(logGroupName string) tea.Cmd {
-	return func() tea.Msg {
-		endTime := time.Now()
-		startTime := endTime.Add(-30 * time.Minute)
+	return m.loadMatchingLogEvents(logGroupName, m.filterPattern)
+}
 
+// loadMatchingLogEvents reads only the events matching a CloudWatch filter
+// pattern, filtered server-side across the whole time range
+func (m Model) loadMatchingLogEvents(logGroupName, pattern string) tea.Cmd {
+	startTime, endTime := m.timeRange.bounds(time.Now())
+	return func() tea.Msg {
 		allEvents := []types.FilteredLogEvent{}
 		var nextToken *string
 
@@ -96,23 +98,38 @@ func (m Model) loadLogEvents(logGroupName string) tea.Cmd {
 			result, err := m.client.FilterLogEvents(
 				context.TODO(),
 				&cloudwatchlogs.FilterLogEventsInput{
-					LogGroupName: aws.String(logGroupName),
-					StartTime:    aws.Int64(startTime.UnixMilli()),
-					EndTime:      aws.Int64(endTime.UnixMilli()),
-					Limit:        aws.Int32(500),
-					NextToken:    nextToken,
+					LogGroupName:  aws.String(logGroupName),
+					StartTime:     aws.Int64(startTime.UnixMilli()),
+					EndTime:       aws.Int64(endTime.UnixMilli()),
+					FilterPattern: filterPatternInput(pattern),
+					Limit:         aws.Int32(500),
+					NextToken:     nextToken,
 				},
 			)
 			if err != nil {
-				return logEventsLoadedMsg{err: err}
+				return logEventsLoadedMsg{pattern: pattern, err: err}
 			}
 			allEvents = append(allEvents, result.Events...)
+			if len(allEvents) >= maxLogEvents {
+				// The loaded events end at the last one kept, not at the range's end
+				events := allEvents[:maxLogEvents]
+				until := aws.ToInt64(events[len(events)-1].Timestamp)
+				return logEventsLoadedMsg{events: events, truncated: true, until: until, pattern: pattern}
+			}
 
 			if result.NextToken == nil {
 				break
 			}
 			nextToken = result.NextToken
 		}
-		return logEventsLoadedMsg{events: allEvents}
+		return logEventsLoadedMsg{events: allEvents, until: endTime.UnixMilli(), pattern: pattern}
+	}
+}
+
+// filterPatternInput leaves the filter pattern out when there is none
+func filterPatternInput(pattern string) *string {
+	if pattern == "" {
+		return nil
 	}
+	return aws.String(pattern)
 }
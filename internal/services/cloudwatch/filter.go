@@ -0,0 +1,325 @@
+package cloudwatch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// filterMode is what the filter prompt does with a pattern
+type filterMode int
+
+const (
+	filterModeCloudWatch filterMode = iota // Reload with a server-side CloudWatch filter pattern
+	filterModeRegexp                       // Refine the loaded events with Go's regexp
+	filterModeRipgrep                      // Refine the loaded events with the rg binary
+)
+
+func (f filterMode) String() string {
+	switch f {
+	case filterModeRegexp:
+		return "Regexp"
+	case filterModeRipgrep:
+		return "Ripgrep"
+	}
+	return "CloudWatch Pattern"
+}
+
+func (f filterMode) placeholder() string {
+	switch f {
+	case filterModeRegexp:
+		return "Go regexp (e.g., ERROR|WARN)"
+	case filterModeRipgrep:
+		return "ripgrep pattern (e.g., ERROR|WARN)"
+	}
+	return "CloudWatch filter pattern (e.g., ?ERROR ?WARN)"
+}
+
+// Most context lines the prompt offers around each match
+const maxFilterContext = 20
+
+var (
+	highlightStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("0")).
+			Background(lipgloss.Color("220"))
+
+	contextStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("245"))
+)
+
+// localFilter is one step of a chain refining the loaded log lines
+type localFilter struct {
+	pattern    string
+	ignoreCase bool
+	invert     bool // Keep the lines that don't match
+	context    int  // Lines kept around each match
+	ripgrep    bool // Run by rg instead of Go's regexp
+}
+
+func (f localFilter) String() string {
+	s := "/" + f.pattern + "/"
+	if f.ignoreCase {
+		s += "i"
+	}
+	if f.invert {
+		s = "!" + s
+	}
+	if f.context > 0 {
+		s += fmt.Sprintf(" ±%d", f.context)
+	}
+	if f.ripgrep {
+		s += " (rg)"
+	}
+	return s
+}
+
+// regexp compiles the pattern with Go's syntax, which rg patterns mostly share
+func (f localFilter) regexp() (*regexp.Regexp, error) {
+	pattern := f.pattern
+	if f.ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// filterResult is the output of one filter, and the input of the next
+type filterResult struct {
+	lines   []int  // Kept lines, as indexes into the filter's input
+	matched []bool // False for context lines
+	matches int
+}
+
+// collectLines lists the kept lines in order
+func collectLines(keep, isMatch []bool) filterResult {
+	var result filterResult
+	for i := range keep {
+		if !keep[i] {
+			continue
+		}
+		if isMatch[i] {
+			result.matches++
+		}
+		result.lines = append(result.lines, i)
+		result.matched = append(result.matched, isMatch[i])
+	}
+	return result
+}
+
+// applyRegexpFilter runs a filter in-process
+func applyRegexpFilter(lines []string, f localFilter) (filterResult, error) {
+	re, err := f.regexp()
+	if err != nil {
+		return filterResult{}, fmt.Errorf("invalid pattern %q: %w", f.pattern, err)
+	}
+
+	isMatch := make([]bool, len(lines))
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if isMatch[i] = re.MatchString(line) != f.invert; !isMatch[i] {
+			continue
+		}
+		for j := max(i-f.context, 0); j <= min(i+f.context, len(lines)-1); j++ {
+			keep[j] = true
+		}
+	}
+	return collectLines(keep, isMatch), nil
+}
+
+// applyRipgrepFilter runs a filter with rg. Line numbers in its output tell
+// matches ("12:") from context ("12-") and map them back to the input.
+func applyRipgrepFilter(lines []string, f localFilter) (filterResult, error) {
+	args := []string{"--no-config", "--color", "never", "--line-number"}
+	if f.ignoreCase {
+		args = append(args, "--ignore-case")
+	}
+	if f.invert {
+		args = append(args, "--invert-match")
+	}
+	if f.context > 0 {
+		args = append(args, "--context", strconv.Itoa(f.context))
+	}
+	args = append(args, "--regexp", f.pattern)
+
+	cmd := exec.Command("rg", args...)
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	var exitErr *exec.ExitError
+	err := cmd.Run()
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return filterResult{}, fmt.Errorf("ripgrep (rg) is not installed; press Tab in the filter prompt to use the built-in regexp engine")
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// Exit status 1 only means nothing matched
+		return filterResult{}, nil
+	case errors.As(err, &exitErr):
+		return filterResult{}, fmt.Errorf("rg exited with status %d: %s", exitErr.ExitCode(), strings.TrimSpace(stderr.String()))
+	case err != nil:
+		return filterResult{}, fmt.Errorf("failed to run rg: %w", err)
+	}
+
+	isMatch := make([]bool, len(lines))
+	keep := make([]bool, len(lines))
+	for _, out := range strings.Split(stdout.String(), "\n") {
+		digits := strings.IndexFunc(out, func(r rune) bool { return r < '0' || r > '9' })
+		if digits <= 0 {
+			continue // "--" between groups
+		}
+		n, err := strconv.Atoi(out[:digits])
+		if err != nil || n < 1 || n > len(lines) {
+			continue
+		}
+		keep[n-1] = true
+		isMatch[n-1] = out[digits] == ':'
+	}
+	return collectLines(keep, isMatch), nil
+}
+
+// applyFilters runs a chain of filters, each over the previous one's output.
+// The result indexes the original lines.
+func applyFilters(lines []string, filters []localFilter) (filterResult, []int, error) {
+	result := filterResult{lines: make([]int, len(lines)), matched: make([]bool, len(lines))}
+	for i := range lines {
+		result.lines[i] = i
+	}
+	counts := make([]int, len(filters))
+
+	input := lines
+	for i, f := range filters {
+		var next filterResult
+		var err error
+		if f.ripgrep {
+			next, err = applyRipgrepFilter(input, f)
+		} else {
+			next, err = applyRegexpFilter(input, f)
+		}
+		if err != nil {
+			return filterResult{}, nil, err
+		}
+		counts[i] = next.matches
+
+		origin := make([]int, len(next.lines))
+		input = make([]string, len(next.lines))
+		for j, k := range next.lines {
+			origin[j] = result.lines[k]
+			input[j] = lines[origin[j]]
+		}
+		next.lines = origin
+		result = next
+	}
+	return result, counts, nil
+}
+
+// highlightLine marks every match of the highlight patterns; context lines are dimmed
+func highlightLine(line string, patterns []*regexp.Regexp, matched bool) string {
+	base := lipgloss.NewStyle()
+	if !matched {
+		base = contextStyle
+	}
+
+	marked := make([]bool, len(line))
+	found := false
+	for _, re := range patterns {
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			for i := loc[0]; i < loc[1]; i++ {
+				marked[i] = true
+				found = true
+			}
+		}
+	}
+	if !found {
+		return base.Render(line)
+	}
+
+	var b strings.Builder
+	start := 0
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && marked[i] == marked[start] {
+			continue
+		}
+		if marked[start] {
+			b.WriteString(highlightStyle.Render(line[start:i]))
+		} else {
+			b.WriteString(base.Render(line[start:i]))
+		}
+		start = i
+	}
+	return b.String()
+}
+
+type filteredLogsMsg struct {
+	gen    int
+	output string
+	lines  int   // Lines left after the last filter
+	counts []int // Matching lines per filter
+	added  bool  // The last filter was just added, rather than the logs changing
+	err    error
+}
+
+// logLines returns the loaded events as plain text lines for filtering
+func (m Model) logLines() []string {
+	layout := m.timeRange.timestampLayout()
+	var lines []string
+	for _, event := range m.logEvents {
+		if event.Message == nil {
+			continue
+		}
+		timestamp := time.UnixMilli(*event.Timestamp).In(m.timeRange.location()).Format(layout)
+		message := strings.TrimSpace(*event.Message)
+		for i, line := range strings.Split(message, "\n") {
+			if i == 0 {
+				line = timestamp + " " + line
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// runLocalFilters applies the filter chain to the loaded events
+func (m Model) runLocalFilters(added bool) tea.Cmd {
+	gen := m.filterGen
+	lines := m.logLines()
+	filters := append([]localFilter(nil), m.localFilters...)
+
+	return func() tea.Msg {
+		result, counts, err := applyFilters(lines, filters)
+		if err != nil {
+			return filteredLogsMsg{gen: gen, added: added, err: err}
+		}
+
+		var highlights []*regexp.Regexp
+		for _, f := range filters {
+			if f.invert {
+				continue
+			}
+			if re, err := f.regexp(); err == nil {
+				highlights = append(highlights, re)
+			}
+		}
+
+		var b strings.Builder
+		for i, line := range result.lines {
+			// Separate lines that weren't next to each other in the logs
+			if i > 0 && line != result.lines[i-1]+1 {
+				b.WriteString(contextStyle.Render("--") + "\n")
+			}
+			b.WriteString(highlightLine(lines[line], highlights, result.matched[i]) + "\n")
+		}
+		if len(result.lines) == 0 {
+			b.WriteString("No matches found\n")
+		}
+
+		return filteredLogsMsg{gen: gen, output: b.String(), lines: len(result.lines), counts: counts, added: added}
+	}
+}
@@ -0,0 +1,130 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestApplyFilters(t *testing.T) {
+	lines := []string{
+		"INFO start",       // 0
+		"DEBUG cache miss", // 1
+		"ERROR db timeout", // 2
+		"INFO retry",       // 3
+		"error db refused", // 4
+		"INFO done",        // 5
+		"WARN slow",        // 6
+	}
+
+	tests := []struct {
+		name    string
+		filters []localFilter
+		lines   []int
+		matched []bool
+		counts  []int
+	}{
+		{
+			name:    "no filters keeps everything",
+			lines:   []int{0, 1, 2, 3, 4, 5, 6},
+			matched: []bool{false, false, false, false, false, false, false},
+			counts:  []int{},
+		},
+		{
+			name:    "single match",
+			filters: []localFilter{{pattern: "ERROR"}},
+			lines:   []int{2},
+			matched: []bool{true},
+			counts:  []int{1},
+		},
+		{
+			name:    "ignore case",
+			filters: []localFilter{{pattern: "error", ignoreCase: true}},
+			lines:   []int{2, 4},
+			matched: []bool{true, true},
+			counts:  []int{2},
+		},
+		{
+			name:    "invert",
+			filters: []localFilter{{pattern: "INFO", invert: true}},
+			lines:   []int{1, 2, 4, 6},
+			matched: []bool{true, true, true, true},
+			counts:  []int{4},
+		},
+		{
+			name:    "context merges overlapping windows",
+			filters: []localFilter{{pattern: "db", context: 1}},
+			lines:   []int{1, 2, 3, 4, 5},
+			matched: []bool{false, true, false, true, false},
+			counts:  []int{2},
+		},
+		{
+			name:    "context stops at the edges",
+			filters: []localFilter{{pattern: "start|slow", context: 2}},
+			lines:   []int{0, 1, 2, 4, 5, 6},
+			matched: []bool{true, false, false, false, false, true},
+			counts:  []int{2},
+		},
+		{
+			name: "chain refines the previous output",
+			filters: []localFilter{
+				{pattern: "db|INFO"},
+				{pattern: "INFO", invert: true},
+			},
+			lines:   []int{2, 4},
+			matched: []bool{true, true},
+			counts:  []int{5, 2},
+		},
+		{
+			name: "context only reaches lines the previous filter kept",
+			filters: []localFilter{
+				{pattern: "INFO|WARN"},
+				{pattern: "retry", context: 1},
+			},
+			lines:   []int{0, 3, 5},
+			matched: []bool{false, true, false},
+			counts:  []int{4, 1},
+		},
+		{
+			name: "chain to nothing",
+			filters: []localFilter{
+				{pattern: "ERROR"},
+				{pattern: "INFO"},
+			},
+			counts: []int{1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, counts, err := applyFilters(lines, tt.filters)
+			if err != nil {
+				t.Fatalf("applyFilters failed: %v", err)
+			}
+			// Compared as text, so nil and empty results are the same
+			if got, want := fmt.Sprint(result.lines), fmt.Sprint(tt.lines); got != want {
+				t.Errorf("lines = %v, want %v", got, want)
+			}
+			if got, want := fmt.Sprint(result.matched), fmt.Sprint(tt.matched); got != want {
+				t.Errorf("matched = %v, want %v", got, want)
+			}
+			if got, want := fmt.Sprint(counts), fmt.Sprint(tt.counts); got != want {
+				t.Errorf("counts = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyFiltersInvalidPattern(t *testing.T) {
+	_, _, err := applyFilters([]string{"x"}, []localFilter{{pattern: "("}})
+	if err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Fatalf("err = %v, want an invalid pattern error", err)
+	}
+}
+
+func TestLocalFilterString(t *testing.T) {
+	f := localFilter{pattern: "a|b", ignoreCase: true, invert: true, context: 3, ripgrep: true}
+	if got, want := f.String(), "!/a|b/i ±3 (rg)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,574 @@
+package cloudwatch
+
+import (
+	"cirrus/internal/config"
+	"cirrus/internal/messages"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// insightsPollInterval is how often a running query's results are fetched
+const insightsPollInterval = time.Second
+
+// StartQuery accepts at most this many log groups
+const maxInsightsGroups = 50
+
+// Widest a result column is drawn; the rest is cut off
+const maxInsightsColumnWidth = 60
+
+const defaultInsightsQuery = "fields @timestamp, @message\n| sort @timestamp desc\n| limit 100"
+
+type insightsStartedMsg struct {
+	run     int
+	queryID string
+	err     error
+}
+
+type insightsPollMsg struct {
+	queryID string
+}
+
+type insightsResultsMsg struct {
+	queryID string
+	status  types.QueryStatus
+	results [][]types.ResultField
+	stats   *types.QueryStatistics
+	err     error
+}
+
+type insightsStoppedMsg struct {
+	err error
+}
+
+func newInsightsInput(width int) textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "fields @timestamp, @message | filter @message like /ERROR/"
+	ta.ShowLineNumbers = false
+	ta.SetWidth(max(width-4, 40))
+	ta.SetHeight(6)
+	ta.Focus()
+	return ta
+}
+
+// openInsights shows the query console for the log group under the cursor
+func (m Model) openInsights(logGroup string) (tea.Model, tea.Cmd) {
+	m.insightsReturn = m.state
+	m.insightsGroups = map[string]bool{logGroup: true}
+	m.insightsSavedIdx = -1
+	m.insightsNaming = false
+
+	if m.insightsInput.Value() == "" {
+		m.insightsInput = newInsightsInput(m.Width)
+		m.insightsInput.SetValue(defaultInsightsQuery)
+	}
+	m.insightsInput.Focus()
+
+	m.state = stateInsightsQuery
+	return m, textarea.Blink
+}
+
+// selectedInsightsGroups returns the chosen log group names in list order
+func (m Model) selectedInsightsGroups() []string {
+	var groups []string
+	for _, group := range m.logGroups {
+		if name := aws.ToString(group.LogGroupName); m.insightsGroups[name] {
+			groups = append(groups, name)
+		}
+	}
+	// A group opened from the log stream may since have left the list
+	for name := range m.insightsGroups {
+		if !slices.Contains(groups, name) {
+			groups = append(groups, name)
+		}
+	}
+	return groups
+}
+
+// savedQueries returns the saved queries of every selected group, first name wins
+func (m Model) savedQueries() []config.SavedQuery {
+	var queries []config.SavedQuery
+	seen := make(map[string]bool)
+	for _, group := range m.selectedInsightsGroups() {
+		for _, q := range m.config.GetSavedQueries(group) {
+			if !seen[q.Name] {
+				seen[q.Name] = true
+				queries = append(queries, q)
+			}
+		}
+	}
+	return queries
+}
+
+func (m Model) updateInsightsQuery(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		m.insightsInput, cmd = m.insightsInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.err != nil {
+		if key.String() == "esc" || key.String() == "enter" {
+			m.err = nil
+		}
+		return m, nil
+	}
+
+	if m.insightsNaming {
+		return m.updateInsightsName(key)
+	}
+
+	switch key.String() {
+	case "esc":
+		m.state = m.insightsReturn
+		return m, nil
+
+	case "ctrl+s":
+		if strings.TrimSpace(m.insightsInput.Value()) == "" {
+			return m, nil
+		}
+		if len(m.insightsGroups) == 0 {
+			m.err = fmt.Errorf("select at least one log group to query with Ctrl+G")
+			return m, nil
+		}
+		return m.runInsightsQuery()
+
+	case "ctrl+g":
+		m.insightsGroupCursor = 0
+		m.state = stateInsightsGroups
+		return m, nil
+
+	case "ctrl+t":
+		return m.openTimeRange()
+
+	case "ctrl+p", "ctrl+n":
+		saved := m.savedQueries()
+		if len(saved) == 0 {
+			return m, nil
+		}
+		if key.String() == "ctrl+n" {
+			m.insightsSavedIdx = (m.insightsSavedIdx + 1) % len(saved)
+		} else {
+			// From no selection, the previous query is the last one
+			m.insightsSavedIdx = (max(m.insightsSavedIdx, 0) - 1 + len(saved)) % len(saved)
+		}
+		m.insightsInput.SetValue(saved[m.insightsSavedIdx].Query)
+		return m, nil
+
+	case "ctrl+a":
+		if strings.TrimSpace(m.insightsInput.Value()) == "" {
+			return m, nil
+		}
+		m.insightsName = textinput.New()
+		m.insightsName.Placeholder = "Name for this query"
+		m.insightsName.Width = 40
+		if saved := m.savedQueries(); m.insightsSavedIdx >= 0 && m.insightsSavedIdx < len(saved) {
+			m.insightsName.SetValue(saved[m.insightsSavedIdx].Name)
+		}
+		m.insightsName.Focus()
+		m.insightsInput.Blur()
+		m.insightsNaming = true
+		return m, textinput.Blink
+
+	case "ctrl+d":
+		saved := m.savedQueries()
+		if m.insightsSavedIdx < 0 || m.insightsSavedIdx >= len(saved) {
+			return m, nil
+		}
+		name := saved[m.insightsSavedIdx].Name
+		for _, group := range m.selectedInsightsGroups() {
+			m.config.DeleteSavedQuery(group, name)
+		}
+		m.insightsSavedIdx = -1
+		if err := m.config.Save(); err != nil {
+			return m, messages.ShowToast("Failed to save config", messages.ToastError)
+		}
+		return m, messages.ShowToast(fmt.Sprintf("Deleted query %q", name), messages.ToastSuccess)
+	}
+
+	m.insightsInput, cmd = m.insightsInput.Update(msg)
+	return m, cmd
+}
+
+// updateInsightsName asks for a name and saves the query for every selected group
+func (m Model) updateInsightsName(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.insightsNaming = false
+		m.insightsInput.Focus()
+		return m, nil
+
+	case "enter":
+		name := strings.TrimSpace(m.insightsName.Value())
+		if name == "" {
+			return m, nil
+		}
+		query := config.SavedQuery{Name: name, Query: strings.TrimSpace(m.insightsInput.Value())}
+		for _, group := range m.selectedInsightsGroups() {
+			m.config.SaveQuery(group, query)
+		}
+		m.insightsNaming = false
+		m.insightsInput.Focus()
+
+		for i, q := range m.savedQueries() {
+			if q.Name == name {
+				m.insightsSavedIdx = i
+			}
+		}
+		if err := m.config.Save(); err != nil {
+			return m, messages.ShowToast("Failed to save config", messages.ToastError)
+		}
+		return m, messages.ShowToast(fmt.Sprintf("Saved query %q", name), messages.ToastSuccess)
+	}
+
+	var cmd tea.Cmd
+	m.insightsName, cmd = m.insightsName.Update(msg)
+	return m, cmd
+}
+
+func (m Model) updateInsightsGroups(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if m.insightsGroupCursor > 0 {
+			m.insightsGroupCursor--
+		}
+	case "down", "j":
+		if m.insightsGroupCursor < len(m.logGroups)-1 {
+			m.insightsGroupCursor++
+		}
+	case " ":
+		if m.insightsGroupCursor < len(m.logGroups) {
+			name := aws.ToString(m.logGroups[m.insightsGroupCursor].LogGroupName)
+			if m.insightsGroups[name] {
+				delete(m.insightsGroups, name)
+			} else if len(m.insightsGroups) < maxInsightsGroups {
+				m.insightsGroups[name] = true
+			}
+		}
+	case "a":
+		// Groups picked earlier count toward the limit too
+		for _, group := range m.logGroups {
+			if len(m.insightsGroups) >= maxInsightsGroups {
+				break
+			}
+			m.insightsGroups[aws.ToString(group.LogGroupName)] = true
+		}
+	case "enter":
+		if len(m.insightsGroups) > 0 {
+			m.insightsSavedIdx = -1
+			m.state = stateInsightsQuery
+		}
+	case "esc":
+		// Running a query without groups is refused there
+		m.insightsSavedIdx = -1
+		m.state = stateInsightsQuery
+	}
+	return m, nil
+}
+
+// runInsightsQuery starts the query over the selected groups and time range
+func (m Model) runInsightsQuery() (tea.Model, tea.Cmd) {
+	groups := m.selectedInsightsGroups()
+	query := strings.TrimSpace(m.insightsInput.Value())
+	start, end := m.timeRange.bounds(time.Now())
+
+	m.insightsRun++
+	run := m.insightsRun
+	m.insightsQueryID = ""
+	m.insightsStatus = types.QueryStatusScheduled
+	m.insightsStats = nil
+	m.insightsMatched = 0
+	m.state = stateInsightsRunning
+
+	return m, tea.Batch(
+		func() tea.Msg {
+			result, err := m.client.StartQuery(context.TODO(), &cloudwatchlogs.StartQueryInput{
+				LogGroupNames: groups,
+				QueryString:   aws.String(query),
+				StartTime:     aws.Int64(start.Unix()),
+				EndTime:       aws.Int64(end.Unix()),
+			})
+			if err != nil {
+				return insightsStartedMsg{run: run, err: err}
+			}
+			return insightsStartedMsg{run: run, queryID: aws.ToString(result.QueryId)}
+		},
+		m.spinner.Tick,
+	)
+}
+
+func (m Model) handleInsightsStarted(msg insightsStartedMsg) (tea.Model, tea.Cmd) {
+	if msg.run != m.insightsRun {
+		// Cancelled before it started
+		if msg.queryID != "" {
+			return m, m.stopInsightsQuery(msg.queryID)
+		}
+		return m, nil
+	}
+	if msg.err != nil {
+		m.err = msg.err
+		m.state = stateInsightsQuery
+		return m, nil
+	}
+
+	m.insightsQueryID = msg.queryID
+	return m, m.pollInsightsTick()
+}
+
+func (m Model) pollInsightsTick() tea.Cmd {
+	queryID := m.insightsQueryID
+	return tea.Tick(insightsPollInterval, func(time.Time) tea.Msg {
+		return insightsPollMsg{queryID: queryID}
+	})
+}
+
+func (m Model) pollInsightsResults(queryID string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := m.client.GetQueryResults(context.TODO(), &cloudwatchlogs.GetQueryResultsInput{
+			QueryId: aws.String(queryID),
+		})
+		if err != nil {
+			return insightsResultsMsg{queryID: queryID, err: err}
+		}
+		return insightsResultsMsg{
+			queryID: queryID,
+			status:  result.Status,
+			results: result.Results,
+			stats:   result.Statistics,
+		}
+	}
+}
+
+func (m Model) handleInsightsPoll(msg insightsPollMsg) (tea.Model, tea.Cmd) {
+	if msg.queryID == "" || msg.queryID != m.insightsQueryID {
+		return m, nil
+	}
+	return m, m.pollInsightsResults(msg.queryID)
+}
+
+func (m Model) handleInsightsResults(msg insightsResultsMsg) (tea.Model, tea.Cmd) {
+	if msg.queryID != m.insightsQueryID {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.insightsQueryID = ""
+		m.err = msg.err
+		m.state = stateInsightsQuery
+		return m, nil
+	}
+
+	m.insightsStatus = msg.status
+	m.insightsStats = msg.stats
+	m.insightsMatched = len(msg.results)
+
+	switch msg.status {
+	case types.QueryStatusScheduled, types.QueryStatusRunning:
+		return m, m.pollInsightsTick()
+
+	case types.QueryStatusComplete:
+		m.insightsQueryID = ""
+		m.insightsColumns, m.insightsRows = insightsRows(msg.results)
+		m.insightsSortCol = -1
+		m.insightsSortDesc = false
+		m.buildInsightsTable()
+		m.state = stateInsightsResults
+		return m, nil
+	}
+
+	m.insightsQueryID = ""
+	m.err = fmt.Errorf("query ended with status %s", msg.status)
+	m.state = stateInsightsQuery
+	return m, nil
+}
+
+// cancelInsightsQuery stops the running query and returns to the console
+func (m Model) cancelInsightsQuery() (tea.Model, tea.Cmd) {
+	queryID := m.insightsQueryID
+	m.insightsRun++
+	m.insightsQueryID = ""
+	m.state = stateInsightsQuery
+
+	if queryID == "" {
+		// Still starting; handleInsightsStarted stops it
+		return m, nil
+	}
+	return m, m.stopInsightsQuery(queryID)
+}
+
+func (m Model) stopInsightsQuery(queryID string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := m.client.StopQuery(context.TODO(), &cloudwatchlogs.StopQueryInput{
+			QueryId: aws.String(queryID),
+		})
+		return insightsStoppedMsg{err: err}
+	}
+}
+
+func (m Model) handleInsightsStopped(msg insightsStoppedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		return m, messages.ShowToast("Failed to stop query: "+msg.err.Error(), messages.ToastError)
+	}
+	return m, messages.ShowToast("Query cancelled", messages.ToastInfo)
+}
+
+// insightsRows turns query results into columns in order of first appearance
+// and rows of cell values. The @ptr field only links back to the record.
+func insightsRows(results [][]types.ResultField) ([]string, [][]string) {
+	var columns []string
+	index := make(map[string]int)
+	for _, record := range results {
+		for _, field := range record {
+			name := aws.ToString(field.Field)
+			if _, ok := index[name]; ok || name == "@ptr" {
+				continue
+			}
+			index[name] = len(columns)
+			columns = append(columns, name)
+		}
+	}
+
+	rows := make([][]string, 0, len(results))
+	for _, record := range results {
+		row := make([]string, len(columns))
+		for _, field := range record {
+			if i, ok := index[aws.ToString(field.Field)]; ok {
+				row[i] = strings.ReplaceAll(aws.ToString(field.Value), "\n", " ")
+			}
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows
+}
+
+// compareCells orders numbers numerically and everything else as text
+func compareCells(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// buildInsightsTable sorts the rows by the selected column and rebuilds the table
+func (m *Model) buildInsightsTable() {
+	rows := make([][]string, len(m.insightsRows))
+	copy(rows, m.insightsRows)
+	if col := m.insightsSortCol; col >= 0 {
+		sort.SliceStable(rows, func(i, j int) bool {
+			c := compareCells(rows[i][col], rows[j][col])
+			if m.insightsSortDesc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+
+	columns := make([]table.Column, len(m.insightsColumns))
+	for i, name := range m.insightsColumns {
+		width := len(name) + 2
+		for _, row := range rows {
+			width = max(width, lipgloss.Width(row[i]))
+		}
+		title := name
+		if i == m.insightsSortCol {
+			if m.insightsSortDesc {
+				title += " ▼"
+			} else {
+				title += " ▲"
+			}
+		}
+		columns[i] = table.Column{Title: title, Width: min(width, maxInsightsColumnWidth)}
+	}
+
+	tableRows := make([]table.Row, len(rows))
+	for i, row := range rows {
+		tableRows[i] = table.Row(row)
+	}
+
+	cursor := m.insightsTable.Cursor()
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithRows(tableRows),
+		table.WithFocused(true),
+		table.WithHeight(max(min(len(tableRows), m.Height-12), 5)),
+	)
+
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		BorderBottom(true).
+		Bold(false)
+	s.Selected = s.Selected.
+		Foreground(lipgloss.Color("229")).
+		Background(lipgloss.Color("57")).
+		Bold(false)
+	t.SetStyles(s)
+
+	t.SetCursor(min(cursor, max(len(tableRows)-1, 0)))
+	m.insightsTable = t
+}
+
+func (m Model) updateInsightsResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.state = stateInsightsQuery
+		return m, nil
+
+	case "left", "h":
+		if len(m.insightsColumns) > 0 {
+			if m.insightsSortCol <= 0 {
+				m.insightsSortCol = len(m.insightsColumns) - 1
+			} else {
+				m.insightsSortCol--
+			}
+			m.insightsSortDesc = false
+			m.buildInsightsTable()
+		}
+		return m, nil
+
+	case "right", "l":
+		if len(m.insightsColumns) > 0 {
+			m.insightsSortCol = (m.insightsSortCol + 1) % len(m.insightsColumns)
+			m.insightsSortDesc = false
+			m.buildInsightsTable()
+		}
+		return m, nil
+
+	case "s":
+		if m.insightsSortCol >= 0 {
+			m.insightsSortDesc = !m.insightsSortDesc
+			m.buildInsightsTable()
+		}
+		return m, nil
+
+	case "r":
+		return m.runInsightsQuery()
+	}
+
+	var cmd tea.Cmd
+	m.insightsTable, cmd = m.insightsTable.Update(msg)
+	return m, cmd
+}
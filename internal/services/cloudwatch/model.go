@@ -1,9 +1,13 @@
 package cloudwatch
 
 import (
+	"cirrus/internal/config"
+
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -17,10 +21,16 @@ const (
 	stateLogStream
 	stateLoading
 	stateRipgrepInput
+	stateTimeRange
+	stateInsightsQuery
+	stateInsightsGroups
+	stateInsightsRunning
+	stateInsightsResults
 )
 
 type Model struct {
 	client *cloudwatchlogs.Client
+	config *config.Config
 	state  viewState
 
 	// Data
@@ -29,6 +39,45 @@ type Model struct {
 	currentGroup string
 	logEvents    []types.FilteredLogEvent
 	allLogsText  string
+	truncated    bool  // More events matched than maxLogEvents
+	logsUntil    int64 // End of the loaded range in epoch milliseconds
+
+	// Live tail
+	tailing    bool
+	tailPaused bool
+	tailGen    int              // Bumped to drop polls of an earlier tail
+	tailSince  int64            // Timestamp of the newest event seen
+	tailSeen   map[string]int64 // Timestamps of the events within tailOverlap of tailSince, by ID
+	tailErr    error
+
+	// Logs Insights
+	insightsReturn      viewState
+	insightsGroups      map[string]bool // Log group names the query runs over
+	insightsGroupCursor int
+	insightsInput       textarea.Model
+	insightsSavedIdx    int // Saved query shown in the editor, -1 for none
+	insightsNaming      bool
+	insightsName        textinput.Model
+	insightsRun         int    // Bumped to drop the replies of a cancelled query
+	insightsQueryID     string // Running query, empty when none
+	insightsStatus      types.QueryStatus
+	insightsStats       *types.QueryStatistics
+	insightsMatched     int
+	insightsColumns     []string
+	insightsRows        [][]string
+	insightsTable       table.Model
+	insightsSortCol     int // -1 keeps the query's order
+	insightsSortDesc    bool
+
+	// Time range
+	timeRange   timeRange
+	rangeReturn viewState // State to go back to from the picker
+	rangeCursor int       // A preset, or len(rangePresets) for the custom range
+	rangeStart  textinput.Model
+	rangeEnd    textinput.Model
+	rangeFocus  int // 0 for the start field, 1 for the end
+	rangeUTC    bool
+	rangeErr    string
 
 	// AWS
 	env string
@@ -43,14 +92,30 @@ type Model struct {
 	Width  int
 	Height int
 
-	ripgrepInput textinput.Model // ← New
-	filteredView bool
+	ripgrepInput  textinput.Model // ← New
+	filteredView  bool            // The viewport shows the local filter chain's output
+	filterMode    filterMode      // What the filter prompt does with a pattern
+	filterPattern string          // CloudWatch filter pattern applied when loading events
+
+	// Local filter chain and the prompt's options for the next filter
+	localFilters     []localFilter
+	filterCounts     []int // Matching lines per filter
+	filterLines      int   // Lines left after the chain
+	filterGen        int   // Bumped to drop the output of an outdated run
+	filterIgnoreCase bool
+	filterInvert     bool
+	filterContext    int
 }
 
 func NewModel(client *cloudwatchlogs.Client, env string) Model {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		// If config fails to load, create a new one
+		cfg = config.NewConfig()
+	}
 
 	rgInput := textinput.New()
-	rgInput.Placeholder = "ripgrep pattern (e.g., ERROR|WARN)"
+	rgInput.Placeholder = filterModeCloudWatch.placeholder()
 	rgInput.Focus()
 	rgInput.CharLimit = 100
 	rgInput.Width = 50
@@ -64,17 +129,19 @@ func NewModel(client *cloudwatchlogs.Client, env string) Model {
 
 	return Model{
 		client:       client,
+		config:       cfg,
 		state:        stateLogGroupList,
 		spinner:      sp,
 		viewport:     vp, // ← Add initialized viewport
 		ripgrepInput: rgInput,
+		timeRange:    relativeRange(defaultRange, false),
 		env:          env,
 	}
 }
 
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
-		m.loadLambdaLogGroups(),
+		m.loadLogGroups(),
 		m.spinner.Tick,
 	)
 }
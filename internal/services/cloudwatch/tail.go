@@ -0,0 +1,181 @@
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tailInterval is how often a followed log group is polled for new events
+const tailInterval = 2 * time.Second
+
+// tailOverlap is how far back each poll reaches before the newest event seen.
+// CloudWatch can take a while to make events searchable, so late ones would
+// otherwise be missed; the events seen again are skipped by ID.
+const tailOverlap = 30 * time.Second
+
+// errTailGap refuses to follow when the loaded events stop short of the range's end
+var errTailGap = fmt.Errorf("only the first %d events of this range were loaded, so following would skip the ones after them; pick a shorter time range first", maxLogEvents)
+
+type tailTickMsg struct {
+	gen int
+}
+
+type tailEventsMsg struct {
+	gen    int
+	events []types.FilteredLogEvent
+	err    error
+}
+
+// startTail follows the open log group. A range that doesn't end now is
+// replaced by the same width up to the present before following starts.
+func (m Model) startTail() (tea.Model, tea.Cmd) {
+	m.tailing = true
+	m.tailPaused = false
+	m.tailErr = nil
+
+	if m.timeRange.last == 0 {
+		m.timeRange = relativeRange(m.timeRange.width(), m.timeRange.utc)
+		m.state = stateLoading
+		return m, m.loadLogEvents(m.currentGroup)
+	}
+	if m.truncated {
+		m.stopTail()
+		m.err = errTailGap
+		return m, nil
+	}
+	m.resetTailCursor()
+	return m, m.tailTick()
+}
+
+func (m *Model) stopTail() {
+	m.tailing = false
+	m.tailPaused = false
+	m.tailErr = nil
+	m.tailGen++
+}
+
+// resetTailCursor continues from the newest loaded event, or the end of the
+// loaded range if there were none
+func (m *Model) resetTailCursor() {
+	m.tailGen++
+	m.tailSince = 0
+	m.tailSeen = make(map[string]int64)
+	if len(m.logEvents) == 0 {
+		m.tailSince = m.logsUntil
+	}
+	m.advanceTailCursor(m.logEvents)
+}
+
+// advanceTailCursor moves the cursor to the newest of events. Events within
+// the overlap before it are remembered by ID, since the next poll reads them again.
+func (m *Model) advanceTailCursor(events []types.FilteredLogEvent) {
+	for _, event := range events {
+		ts := aws.ToInt64(event.Timestamp)
+		m.tailSince = max(m.tailSince, ts)
+		m.tailSeen[aws.ToString(event.EventId)] = ts
+	}
+
+	oldest := m.tailSince - tailOverlap.Milliseconds()
+	for id, ts := range m.tailSeen {
+		if ts < oldest {
+			delete(m.tailSeen, id)
+		}
+	}
+}
+
+func (m Model) tailTick() tea.Cmd {
+	gen := m.tailGen
+	return tea.Tick(tailInterval, func(time.Time) tea.Msg {
+		return tailTickMsg{gen: gen}
+	})
+}
+
+// pollTail fetches the events written since the last poll
+func (m Model) pollTail() tea.Cmd {
+	gen := m.tailGen
+	group := m.currentGroup
+	since := m.tailSince - tailOverlap.Milliseconds()
+	seen := make(map[string]bool, len(m.tailSeen))
+	for id := range m.tailSeen {
+		seen[id] = true
+	}
+	pattern := m.filterPattern
+
+	return func() tea.Msg {
+		var events []types.FilteredLogEvent
+		var nextToken *string
+
+		for {
+			result, err := m.client.FilterLogEvents(context.TODO(), &cloudwatchlogs.FilterLogEventsInput{
+				LogGroupName:  aws.String(group),
+				StartTime:     aws.Int64(since),
+				FilterPattern: filterPatternInput(pattern),
+				Limit:         aws.Int32(500),
+				NextToken:     nextToken,
+			})
+			if err != nil {
+				return tailEventsMsg{gen: gen, err: err}
+			}
+			for _, event := range result.Events {
+				if !seen[aws.ToString(event.EventId)] {
+					events = append(events, event)
+				}
+			}
+
+			if result.NextToken == nil || len(events) >= maxLogEvents {
+				break
+			}
+			nextToken = result.NextToken
+		}
+
+		return tailEventsMsg{gen: gen, events: events}
+	}
+}
+
+func (m Model) handleTailTick(msg tailTickMsg) (tea.Model, tea.Cmd) {
+	if !m.tailing || msg.gen != m.tailGen {
+		return m, nil
+	}
+	if m.tailPaused {
+		return m, m.tailTick()
+	}
+	return m, m.pollTail()
+}
+
+// handleTailEvents appends new events, keeping the viewport pinned to the
+// bottom unless the user has scrolled up
+func (m Model) handleTailEvents(msg tailEventsMsg) (tea.Model, tea.Cmd) {
+	if !m.tailing || msg.gen != m.tailGen {
+		return m, nil
+	}
+
+	// Errors are shown in the header and polling carries on
+	m.tailErr = msg.err
+	if len(msg.events) > 0 {
+		m.advanceTailCursor(msg.events)
+
+		m.logEvents = append(m.logEvents, msg.events...)
+		if over := len(m.logEvents) - maxLogEvents; over > 0 {
+			m.logEvents = m.logEvents[over:]
+		}
+
+		m.allLogsText = m.renderLogs()
+		if len(m.localFilters) > 0 {
+			m.filterGen++
+			return m, tea.Batch(m.runLocalFilters(false), m.tailTick())
+		}
+		pinned := m.viewport.AtBottom()
+		m.viewport.SetContent(m.allLogsText)
+		if pinned {
+			m.viewport.GotoBottom()
+		}
+	}
+
+	return m, m.tailTick()
+}
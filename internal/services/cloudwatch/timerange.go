@@ -0,0 +1,280 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultRange is the window shown until another range is picked
+const defaultRange = 30 * time.Minute
+
+// rangePresets are the relative ranges offered by the picker; the custom range follows them
+var rangePresets = []time.Duration{
+	5 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+}
+
+// Layouts accepted for absolute timestamps, tried in order
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+const rangeInputLayout = "2006-01-02 15:04:05"
+
+// timeRange is either relative ("the last hour", evaluated when logs are
+// loaded) or absolute between start and end
+type timeRange struct {
+	last       time.Duration // Set for relative ranges
+	start, end time.Time
+	utc        bool // Show and enter times in UTC instead of local time
+}
+
+func relativeRange(last time.Duration, utc bool) timeRange {
+	return timeRange{last: last, utc: utc}
+}
+
+// bounds returns the range's start and end as of now
+func (r timeRange) bounds(now time.Time) (time.Time, time.Time) {
+	if r.last > 0 {
+		return now.Add(-r.last), now
+	}
+	return r.start, r.end
+}
+
+func (r timeRange) width() time.Duration {
+	if r.last > 0 {
+		return r.last
+	}
+	return r.end.Sub(r.start)
+}
+
+func (r timeRange) location() *time.Location {
+	if r.utc {
+		return time.UTC
+	}
+	return time.Local
+}
+
+// shift moves the range by its own width, earlier for a negative direction.
+// Moving later up to the present turns it back into a relative range.
+func (r timeRange) shift(direction int, now time.Time) timeRange {
+	start, end := r.bounds(now)
+	step := time.Duration(direction) * r.width()
+	start, end = start.Add(step), end.Add(step)
+
+	if !end.Before(now) {
+		return relativeRange(r.width(), r.utc)
+	}
+	return timeRange{start: start, end: end, utc: r.utc}
+}
+
+// label describes the range for titles
+func (r timeRange) label() string {
+	if r.last > 0 {
+		return "Last " + durationLabel(r.last)
+	}
+	loc := r.location()
+	return fmt.Sprintf("%s → %s",
+		r.start.In(loc).Format("2006-01-02 15:04:05"),
+		r.end.In(loc).Format("2006-01-02 15:04:05 MST"))
+}
+
+// timestampLayout includes the date for absolute ranges and ranges longer than a day
+func (r timeRange) timestampLayout() string {
+	if r.width() > 24*time.Hour || r.last == 0 {
+		return "01-02 15:04:05.000"
+	}
+	return "15:04:05.000"
+}
+
+// durationLabel renders whole days, hours or minutes, e.g. "24 hours"
+func durationLabel(d time.Duration) string {
+	value, unit := int(d/time.Minute), "minute"
+	switch {
+	case d%(24*time.Hour) == 0 && d >= 48*time.Hour:
+		value, unit = int(d/(24*time.Hour)), "day"
+	case d%time.Hour == 0:
+		value, unit = int(d/time.Hour), "hour"
+	}
+	if value != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", value, unit)
+}
+
+// parseTimestamp reads an absolute timestamp; times without an offset are in loc
+func parseTimestamp(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a timestamp like 2006-01-02 15:04[:05]", value)
+}
+
+func newRangeInput(placeholder string) textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = placeholder
+	ti.CharLimit = 30
+	ti.Width = 25
+	return ti
+}
+
+// openTimeRange shows the range picker, prefilled with the current range
+func (m Model) openTimeRange() (tea.Model, tea.Cmd) {
+	m.rangeReturn = m.state
+	m.rangeUTC = m.timeRange.utc
+	m.rangeErr = ""
+
+	m.rangeCursor = len(rangePresets)
+	for i, preset := range rangePresets {
+		if m.timeRange.last == preset {
+			m.rangeCursor = i
+		}
+	}
+
+	start, end := m.timeRange.bounds(time.Now())
+	loc := m.timeRange.location()
+	m.rangeStart = newRangeInput("start, e.g. 2006-01-02 15:04")
+	m.rangeStart.SetValue(start.In(loc).Format(rangeInputLayout))
+	m.rangeEnd = newRangeInput("end")
+	m.rangeEnd.SetValue(end.In(loc).Format(rangeInputLayout))
+	m.rangeFocus = 0
+	m.focusRangeInput()
+
+	m.state = stateTimeRange
+	return m, textinput.Blink
+}
+
+// focusRangeInput focuses the selected custom range field, if the custom range is selected
+func (m *Model) focusRangeInput() {
+	m.rangeStart.Blur()
+	m.rangeEnd.Blur()
+	if m.rangeCursor != len(rangePresets) {
+		return
+	}
+	if m.rangeFocus == 0 {
+		m.rangeStart.Focus()
+	} else {
+		m.rangeEnd.Focus()
+	}
+}
+
+func (m Model) updateTimeRange(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	custom := m.rangeCursor == len(rangePresets)
+
+	switch msg.String() {
+	case "esc":
+		m.state = m.rangeReturn
+		return m, nil
+
+	case "up":
+		if m.rangeCursor > 0 {
+			m.rangeCursor--
+			m.focusRangeInput()
+		}
+		return m, nil
+
+	case "down":
+		if m.rangeCursor < len(rangePresets) {
+			m.rangeCursor++
+			m.focusRangeInput()
+		}
+		return m, nil
+
+	case "tab", "shift+tab":
+		if custom {
+			m.rangeFocus = 1 - m.rangeFocus
+			m.focusRangeInput()
+		}
+		return m, nil
+
+	case "ctrl+t":
+		// Keep the entered instants and show them in the other zone
+		from, to := time.Local, time.UTC
+		if m.rangeUTC {
+			from, to = to, from
+		}
+		for _, input := range []*textinput.Model{&m.rangeStart, &m.rangeEnd} {
+			if t, err := parseTimestamp(input.Value(), from); err == nil {
+				input.SetValue(t.In(to).Format(rangeInputLayout))
+			}
+		}
+		m.rangeUTC = !m.rangeUTC
+		return m, nil
+
+	case "enter":
+		if !custom {
+			m.timeRange = relativeRange(rangePresets[m.rangeCursor], m.rangeUTC)
+			return m.applyTimeRange()
+		}
+
+		loc := timeRange{utc: m.rangeUTC}.location()
+		start, err := parseTimestamp(m.rangeStart.Value(), loc)
+		if err != nil {
+			m.rangeErr = "Start: " + err.Error()
+			return m, nil
+		}
+		end, err := parseTimestamp(m.rangeEnd.Value(), loc)
+		if err != nil {
+			m.rangeErr = "End: " + err.Error()
+			return m, nil
+		}
+		if !end.After(start) {
+			m.rangeErr = "The end must be after the start"
+			return m, nil
+		}
+		m.timeRange = timeRange{start: start, end: end, utc: m.rangeUTC}
+		return m.applyTimeRange()
+	}
+
+	if !custom {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	if m.rangeFocus == 0 {
+		m.rangeStart, cmd = m.rangeStart.Update(msg)
+	} else {
+		m.rangeEnd, cmd = m.rangeEnd.Update(msg)
+	}
+	return m, cmd
+}
+
+// applyTimeRange leaves the picker, reloading the open log group with the new range
+func (m Model) applyTimeRange() (tea.Model, tea.Cmd) {
+	m.rangeErr = ""
+	if m.timeRange.last == 0 {
+		// Only a range that ends now can be followed
+		m.stopTail()
+	}
+	if m.rangeReturn != stateLogStream || m.currentGroup == "" {
+		m.state = m.rangeReturn
+		return m, nil
+	}
+	m.state = stateLoading
+	return m, m.loadLogEvents(m.currentGroup)
+}
+
+// shiftTimeRange pages the open log group one range width earlier or later
+func (m Model) shiftTimeRange(direction int) (tea.Model, tea.Cmd) {
+	if direction > 0 && m.timeRange.last > 0 {
+		// Already showing the present
+		return m, nil
+	}
+	m.timeRange = m.timeRange.shift(direction, time.Now())
+	m.state = stateLoading
+	return m, m.loadLogEvents(m.currentGroup)
+}
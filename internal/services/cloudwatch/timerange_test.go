@@ -0,0 +1,120 @@
+package cloudwatch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+
+	tests := []struct {
+		value string
+		loc   *time.Location
+		want  time.Time
+	}{
+		{"2024-03-01 10:20:30", time.UTC, time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)},
+		{"  2024-03-01 10:20  ", time.UTC, time.Date(2024, 3, 1, 10, 20, 0, 0, time.UTC)},
+		{"2024-03-01T10:20:30", time.UTC, time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)},
+		{"2024-03-01T10:20", time.UTC, time.Date(2024, 3, 1, 10, 20, 0, 0, time.UTC)},
+		{"2024-03-01", time.UTC, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		// Times without an offset are in the given location
+		{"2024-03-01 10:20", berlin, time.Date(2024, 3, 1, 9, 20, 0, 0, time.UTC)},
+		// An explicit offset wins over the location
+		{"2024-03-01T10:20:30+02:00", berlin, time.Date(2024, 3, 1, 8, 20, 30, 0, time.UTC)},
+		{"2024-03-01T10:20:30Z", berlin, time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTimestamp(tt.value, tt.loc)
+			if err != nil {
+				t.Fatalf("parseTimestamp(%q) failed: %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTimestamp(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "yesterday", "2024-13-01", "10:20", "2024-03-01 25:00"} {
+		if _, err := parseTimestamp(value, time.UTC); err == nil {
+			t.Errorf("parseTimestamp(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestTimeRangeShift(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		r         timeRange
+		direction int
+		want      timeRange
+	}{
+		{
+			name:      "relative range moves back its width",
+			r:         relativeRange(time.Hour, true),
+			direction: -1,
+			want:      timeRange{start: at(10, 0), end: at(11, 0), utc: true},
+		},
+		{
+			name:      "absolute range moves back",
+			r:         timeRange{start: at(9, 0), end: at(9, 30)},
+			direction: -1,
+			want:      timeRange{start: at(8, 30), end: at(9, 0)},
+		},
+		{
+			name:      "absolute range moves forward",
+			r:         timeRange{start: at(9, 0), end: at(9, 30)},
+			direction: 1,
+			want:      timeRange{start: at(9, 30), end: at(10, 0)},
+		},
+		{
+			name:      "reaching the present becomes relative",
+			r:         timeRange{start: at(10, 0), end: at(11, 0), utc: true},
+			direction: 1,
+			want:      relativeRange(time.Hour, true),
+		},
+		{
+			name:      "passing the present becomes relative",
+			r:         timeRange{start: at(10, 30), end: at(11, 30)},
+			direction: 1,
+			want:      relativeRange(time.Hour, false),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.r.shift(tt.direction, now)
+			if got.last != tt.want.last || got.utc != tt.want.utc ||
+				!got.start.Equal(tt.want.start) || !got.end.Equal(tt.want.end) {
+				t.Errorf("shift(%d) = %+v, want %+v", tt.direction, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationLabel(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Minute:         "1 minute",
+		5 * time.Minute:     "5 minutes",
+		90 * time.Minute:    "90 minutes",
+		time.Hour:           "1 hour",
+		24 * time.Hour:      "24 hours",
+		7 * 24 * time.Hour:  "7 days",
+		36 * time.Hour:      "36 hours",
+		2 * 24 * time.Hour:  "2 days",
+		49 * time.Hour:      "49 hours",
+		30 * 24 * time.Hour: "30 days",
+	}
+	for d, want := range tests {
+		if got := durationLabel(d); got != want {
+			t.Errorf("durationLabel(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
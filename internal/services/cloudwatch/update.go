@@ -2,16 +2,46 @@ package cloudwatch
 
 import (
 	"cirrus/internal/app/nav"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	// A followed log group keeps polling while the filter prompt or range picker is open
+	switch msg := msg.(type) {
+	case tailTickMsg:
+		return m.handleTailTick(msg)
+	case tailEventsMsg:
+		return m.handleTailEvents(msg)
+	case insightsStartedMsg:
+		return m.handleInsightsStarted(msg)
+	case insightsPollMsg:
+		return m.handleInsightsPoll(msg)
+	case insightsResultsMsg:
+		return m.handleInsightsResults(msg)
+	case insightsStoppedMsg:
+		return m.handleInsightsStopped(msg)
+	case spinner.TickMsg:
+		if m.state != stateInsightsRunning {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
 	if m.state == stateRipgrepInput {
 		return m.updateRipgrepInput(msg)
 	}
+	if m.state == stateInsightsQuery {
+		return m.updateInsightsQuery(msg)
+	}
+	if key, ok := msg.(tea.KeyMsg); ok && m.state == stateTimeRange {
+		return m.updateTimeRange(key)
+	}
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -48,24 +78,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case filteredLogsMsg:
-		if msg.err != nil {
-			m.err = msg.err
-			m.state = stateLogStream
-			m.filteredView = false
-		} else {
-			m.viewport.SetContent(msg.output)
-			m.viewport.GotoTop()
-			m.state = stateLogStream
-			m.filteredView = true
-		}
-		return m, nil
+		return m.handleFilteredLogs(msg)
 
 	case logEventsLoadedMsg:
-		if msg.err != nil {
+		if msg.err != nil && msg.pattern != m.filterPattern {
+			// A rejected filter pattern keeps the events already shown
+			m.err = msg.err
+			m.ripgrepInput.SetValue(msg.pattern)
+			m.state = stateLogStream
+		} else if msg.err != nil {
+			m.stopTail()
 			m.err = msg.err
 			m.state = stateLogGroupList
 		} else {
 			m.logEvents = msg.events
+			m.filterPattern = msg.pattern
+			m.truncated = msg.truncated
+			m.logsUntil = msg.until
 			m.state = stateLogStream
 			m.viewport.SetContent(m.renderLogs())
 			m.viewport.GotoBottom()
@@ -74,6 +103,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.SetContent(m.allLogsText)
 			m.viewport.GotoBottom()
 			m.filteredView = false
+
+			var cmds []tea.Cmd
+			if len(m.localFilters) > 0 {
+				// The filter chain carries over to the new events
+				m.filterGen++
+				cmds = append(cmds, m.runLocalFilters(false))
+			}
+			if m.tailing && m.truncated {
+				m.stopTail()
+				m.err = errTailGap
+			} else if m.tailing {
+				m.resetTailCursor()
+				cmds = append(cmds, m.tailTick())
+			}
+			return m, tea.Batch(cmds...)
 		}
 		return m, nil
 	}
@@ -98,19 +142,65 @@ func (m Model) updateRipgrepInput(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		local := m.filterMode != filterModeCloudWatch
+
 		switch msg.String() {
 		case "esc":
 			m.state = stateLogStream
 			return m, nil
 
+		case "tab":
+			m.filterMode = (m.filterMode + 1) % (filterModeRipgrep + 1)
+			m.ripgrepInput.Placeholder = m.filterMode.placeholder()
+			return m, nil
+
+		case "ctrl+o":
+			if local {
+				m.filterIgnoreCase = !m.filterIgnoreCase
+			}
+			return m, nil
+
+		case "ctrl+x":
+			if local {
+				m.filterInvert = !m.filterInvert
+			}
+			return m, nil
+
+		case "up":
+			if local && m.filterContext < maxFilterContext {
+				m.filterContext++
+			}
+			return m, nil
+
+		case "down":
+			if local && m.filterContext > 0 {
+				m.filterContext--
+			}
+			return m, nil
+
 		case "enter":
 			pattern := m.ripgrepInput.Value()
+			if !local {
+				// Searched server-side over the whole time range
+				m.ripgrepInput.SetValue("")
+				m.state = stateLoading
+				return m, m.loadMatchingLogEvents(m.currentGroup, strings.TrimSpace(pattern))
+			}
+			if pattern == "" {
+				return m, nil
+			}
+
 			m.ripgrepInput.SetValue("")
+			m.localFilters = append(m.localFilters, localFilter{
+				pattern:    pattern,
+				ignoreCase: m.filterIgnoreCase,
+				invert:     m.filterInvert,
+				context:    m.filterContext,
+				ripgrep:    m.filterMode == filterModeRipgrep,
+			})
+			m.filterGen++
 			m.state = stateLoading
-			return m, tea.Batch(
-				m.filterWithRipgrep(pattern),
-				m.spinner.Tick,
-			)
+			return m, m.runLocalFilters(true)
 		}
 	}
 
@@ -118,6 +208,53 @@ func (m Model) updateRipgrepInput(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// handleFilteredLogs shows the output of the local filter chain
+func (m Model) handleFilteredLogs(msg filteredLogsMsg) (tea.Model, tea.Cmd) {
+	if msg.gen != m.filterGen {
+		return m, nil
+	}
+
+	if msg.err != nil {
+		m.err = msg.err
+		if msg.added {
+			// Drop the filter that failed and show what the rest of the chain matched
+			m.localFilters = m.localFilters[:len(m.localFilters)-1]
+			m.state = stateLogStream
+			return m.refreshLocalFilters()
+		}
+		m.localFilters = nil
+		m.filteredView = false
+		m.viewport.SetContent(m.allLogsText)
+		return m, nil
+	}
+
+	pinned := m.viewport.AtBottom()
+	m.filterCounts = msg.counts
+	m.filterLines = msg.lines
+	m.filteredView = true
+	m.viewport.SetContent(msg.output)
+	if msg.added {
+		m.viewport.GotoTop()
+		m.state = stateLogStream
+	} else if pinned {
+		m.viewport.GotoBottom()
+	}
+	return m, nil
+}
+
+// refreshLocalFilters reruns the filter chain after it changed, or shows all
+// loaded events once it's empty
+func (m Model) refreshLocalFilters() (tea.Model, tea.Cmd) {
+	m.filterGen++
+	if len(m.localFilters) == 0 {
+		m.filteredView = false
+		m.viewport.SetContent(m.allLogsText)
+		m.viewport.GotoBottom()
+		return m, nil
+	}
+	return m, m.runLocalFilters(false)
+}
+
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.err != nil {
 		if msg.String() == "esc" || msg.String() == "enter" {
@@ -149,30 +286,66 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		case "r":
 			m.state = stateLoading
-			return m, m.loadLambdaLogGroups()
+			return m, m.loadLogGroups()
+		case "t":
+			return m.openTimeRange()
+		case "i":
+			if len(m.logGroups) > 0 {
+				return m.openInsights(*m.logGroups[m.selectedIdx].LogGroupName)
+			}
 		}
 
 	case stateLogStream:
 		switch msg.String() {
 		case "q", "esc":
+			m.stopTail()
 			m.state = stateLogGroupList
 			m.currentGroup = ""
+			m.filterPattern = ""
+			m.localFilters = nil
+			m.filteredView = false
 			m.logEvents = nil
 			return m, nil
 		case "r":
 			m.state = stateLoading
 			return m, m.loadLogEvents(m.currentGroup)
+		case "t":
+			return m.openTimeRange()
+		case "i":
+			return m.openInsights(m.currentGroup)
+		case "f":
+			if m.tailing {
+				m.stopTail()
+				return m, nil
+			}
+			return m.startTail()
+		case "p", " ":
+			if m.tailing {
+				m.tailPaused = !m.tailPaused
+			}
+			return m, nil
+		case "[":
+			m.stopTail()
+			return m.shiftTimeRange(-1)
+		case "]":
+			return m.shiftTimeRange(1)
 		case "/": // ← Changed from 'f' to '/' (vim-style)
 			m.state = stateRipgrepInput
 			m.ripgrepInput.Focus()
 			return m, nil
 		case "c": // ← Clear filter
-			if m.filteredView {
-				m.filteredView = false
-				m.viewport.SetContent(m.allLogsText)
-				m.viewport.GotoBottom()
+			// Local filters come off one at a time, then the server-side pattern
+			if len(m.localFilters) > 0 {
+				m.localFilters = m.localFilters[:len(m.localFilters)-1]
+				return m.refreshLocalFilters()
+			} else if m.filterPattern != "" {
+				m.state = stateLoading
+				return m, m.loadMatchingLogEvents(m.currentGroup, "")
 			}
 			return m, nil
+		case "C":
+			m.localFilters = nil
+			return m.refreshLocalFilters()
 		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
 			// Quick switch to log group by number
 			idx := int(msg.String()[0] - '1')
@@ -183,6 +356,17 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				return m, m.loadLogEvents(m.currentGroup)
 			}
 		}
+
+	case stateInsightsGroups:
+		return m.updateInsightsGroups(msg)
+
+	case stateInsightsRunning:
+		if msg.String() == "esc" {
+			return m.cancelInsightsQuery()
+		}
+
+	case stateInsightsResults:
+		return m.updateInsightsResults(msg)
 	}
 
 	return m, nil
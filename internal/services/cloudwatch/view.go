@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -51,6 +52,16 @@ func (m Model) View() string {
 		return m.renderLogStream()
 	case stateRipgrepInput:
 		return m.renderRipgrepInput()
+	case stateTimeRange:
+		return m.renderTimeRange()
+	case stateInsightsQuery:
+		return m.renderInsightsQuery()
+	case stateInsightsGroups:
+		return m.renderInsightsGroups()
+	case stateInsightsRunning:
+		return m.renderInsightsRunning()
+	case stateInsightsResults:
+		return m.renderInsightsResults()
 	}
 	return ""
 }
@@ -63,13 +74,38 @@ func (m Model) renderRipgrepInput() string {
 	b.WriteString(titleStyle.Render(title))
 	b.WriteString("\n\n")
 
-	b.WriteString("Enter ripgrep pattern:\n\n")
+	b.WriteString(fmt.Sprintf("Mode: %s\n", selectedStyle.Render(m.filterMode.String())))
+	switch m.filterMode {
+	case filterModeCloudWatch:
+		b.WriteString(fmt.Sprintf("Searched server-side over %s\n\n", strings.ToLower(m.timeRange.label())))
+	default:
+		if len(m.localFilters) > 0 {
+			b.WriteString(fmt.Sprintf("Refines the current %d filtered lines\n\n", m.filterLines))
+		} else {
+			b.WriteString("Refines the loaded events\n\n")
+		}
+	}
 	b.WriteString(m.ripgrepInput.View())
 	b.WriteString("\n\n")
 
-	b.WriteString(helpStyle.Render("Examples: ERROR | 'status.*500' | '\\b(ERROR|WARN)\\b'"))
+	if m.filterMode == filterModeCloudWatch {
+		b.WriteString(helpStyle.Render(`Examples: ERROR | "status 500" | ?ERROR ?WARN | { $.level = "ERROR" } | empty for all events`))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("Enter: Search • Tab: Switch Mode • Esc: Cancel"))
+		return b.String()
+	}
+
+	onOff := func(on bool) string {
+		if on {
+			return "on"
+		}
+		return "off"
+	}
+	b.WriteString(fmt.Sprintf("Ignore case: %s • Invert: %s • Context lines: %d\n",
+		onOff(m.filterIgnoreCase), onOff(m.filterInvert), m.filterContext))
+	b.WriteString(helpStyle.Render("Examples: ERROR | status.*500 | \\b(ERROR|WARN)\\b"))
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("Enter: Search • Esc: Cancel"))
+	b.WriteString(helpStyle.Render("Enter: Add Filter • Tab: Switch Mode • Ctrl+O: Ignore Case • Ctrl+X: Invert • ↑/↓: Context • Esc: Cancel"))
 
 	return b.String()
 }
@@ -85,14 +121,14 @@ func (m Model) renderError() string {
 func (m Model) renderLogGroupList() string {
 	var b strings.Builder
 
-	b.WriteString(titleStyle.Render("Lambda Log Groups"))
+	b.WriteString(titleStyle.Render("Log Groups"))
 	b.WriteString("\n\n")
 
 	if len(m.logGroups) == 0 {
-		b.WriteString("No Lambda log groups found\n")
+		b.WriteString("No log groups found\n")
 	} else {
 		for i, group := range m.logGroups {
-			// Extract Lambda function name from log group
+			// Show Lambda log groups by function name
 			name := *group.LogGroupName
 			functionName := strings.TrimPrefix(name, "/aws/lambda/")
 
@@ -110,7 +146,7 @@ func (m Model) renderLogGroupList() string {
 	b.WriteString("\n")
 	b.WriteString(
 		helpStyle.Render(
-			"↑/↓: Navigate • Enter: View Logs • 1-9: Quick Switch • r: Refresh • q: Back",
+			"↑/↓: Navigate • Enter: View Logs • 1-9: Quick Switch • r: Refresh • t: Time Range • i: Insights • q: Back",
 		),
 	)
 
@@ -121,19 +157,50 @@ func (m Model) renderLogStream() string {
 	var b strings.Builder
 
 	functionName := strings.TrimPrefix(m.currentGroup, "/aws/lambda/")
-	title := fmt.Sprintf("📋 Logs: %s (Last 30 minutes)", functionName)
+	title := fmt.Sprintf("📋 Logs: %s (%s)", functionName, m.timeRange.label())
 	b.WriteString(titleStyle.Render(title))
 	b.WriteString("\n")
 
+	if m.tailing {
+		if m.tailPaused {
+			b.WriteString(timestampStyle.Render("⏸ Following paused"))
+		} else {
+			b.WriteString(logGroupStyle.Render(fmt.Sprintf("● Following (every %s)", tailInterval)))
+		}
+		if m.tailErr != nil {
+			b.WriteString(" " + errorStyle.Render("Poll failed: "+m.tailErr.Error()))
+		}
+		b.WriteString("\n")
+	}
+	if m.filterPattern != "" {
+		b.WriteString(timestampStyle.Render("Filter pattern: " + m.filterPattern))
+		b.WriteString("\n")
+	}
+	if m.filteredView {
+		steps := make([]string, len(m.localFilters))
+		for i, f := range m.localFilters {
+			steps[i] = f.String()
+			if i < len(m.filterCounts) {
+				steps[i] += fmt.Sprintf(" (%d)", m.filterCounts[i])
+			}
+		}
+		b.WriteString(timestampStyle.Render(fmt.Sprintf("Filters: %s • %d lines", strings.Join(steps, " › "), m.filterLines)))
+		b.WriteString("\n")
+	}
+
 	if len(m.logEvents) == 0 {
-		b.WriteString("No log events in the last 30 minutes\n")
+		b.WriteString("No log events in this time range\n")
 	} else {
-		b.WriteString(fmt.Sprintf("Total events: %d\n\n", len(m.logEvents)))
+		total := fmt.Sprintf("Total events: %d", len(m.logEvents))
+		if m.truncated {
+			total += fmt.Sprintf(" (first %d only, narrow the range to see more)", maxLogEvents)
+		}
+		b.WriteString(total + "\n\n")
 		b.WriteString(m.viewport.View())
 	}
 
 	b.WriteString("\n")
-	help := "↑/↓: Scroll • 1-9: Switch Lambda • r: Refresh • /: Ripgrep • Esc: Back to List"
+	help := "↑/↓: Scroll • 1-9: Switch Lambda • r: Refresh • f: Follow • p: Pause • t: Time Range • i: Insights • [/]: Earlier/Later • /: Filter • c: Undo Filter • C: Clear Filters • Esc: Back to List"
 	b.WriteString(helpStyle.Render(help))
 
 	return b.String()
@@ -147,13 +214,14 @@ func (m Model) renderLogs() string {
 	b.WriteString(titleStyle.Render(title))
 	b.WriteString("\n")
 
+	layout := m.timeRange.timestampLayout()
 	for _, event := range m.logEvents {
 		if event.Message == nil {
 			continue
 		}
 
 		message := strings.TrimSpace(*event.Message)
-		timestamp := time.UnixMilli(*event.Timestamp).Format("15:04:05.000")
+		timestamp := time.UnixMilli(*event.Timestamp).In(m.timeRange.location()).Format(layout)
 
 		line := fmt.Sprintf("%s %s", timestampStyle.Render(timestamp), message)
 		b.WriteString(line)
@@ -162,3 +230,148 @@ func (m Model) renderLogs() string {
 
 	return b.String()
 }
+
+func (m Model) renderTimeRange() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("🕒 Time Range"))
+	b.WriteString("\n\n")
+
+	for i, preset := range rangePresets {
+		label := "Last " + durationLabel(preset)
+		if i == m.rangeCursor {
+			b.WriteString(selectedStyle.Render("▶ " + label))
+		} else {
+			b.WriteString("  " + label)
+		}
+		b.WriteString("\n")
+	}
+	if m.rangeCursor == len(rangePresets) {
+		b.WriteString(selectedStyle.Render("▶ Custom range"))
+	} else {
+		b.WriteString("  Custom range")
+	}
+	b.WriteString("\n\n")
+
+	zone := "local time (" + time.Now().Format("MST") + ")"
+	if m.rangeUTC {
+		zone = "UTC"
+	}
+	b.WriteString(fmt.Sprintf("  Start: %s\n", m.rangeStart.View()))
+	b.WriteString(fmt.Sprintf("  End:   %s\n", m.rangeEnd.View()))
+	b.WriteString(fmt.Sprintf("  Times are in %s\n", zone))
+
+	if m.rangeErr != "" {
+		b.WriteString("\n" + errorStyle.Render(m.rangeErr) + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("↑/↓: Select • Tab: Start/End • Ctrl+T: Local/UTC • Enter: Apply • Esc: Cancel"))
+
+	return b.String()
+}
+
+// insightsGroupsLabel names the queried log groups, or counts them when there are several
+func (m Model) insightsGroupsLabel() string {
+	groups := m.selectedInsightsGroups()
+	if len(groups) == 1 {
+		return strings.TrimPrefix(groups[0], "/aws/lambda/")
+	}
+	return fmt.Sprintf("%d log groups", len(groups))
+}
+
+func (m Model) renderInsightsQuery() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf("🔎 Logs Insights: %s (%s)", m.insightsGroupsLabel(), m.timeRange.label())))
+	b.WriteString("\n")
+	b.WriteString(m.insightsInput.View())
+	b.WriteString("\n")
+
+	if saved := m.savedQueries(); len(saved) > 0 {
+		position := "none"
+		if m.insightsSavedIdx >= 0 && m.insightsSavedIdx < len(saved) {
+			position = fmt.Sprintf("%q (%d/%d)", saved[m.insightsSavedIdx].Name, m.insightsSavedIdx+1, len(saved))
+		}
+		b.WriteString(timestampStyle.Render("Saved query: " + position))
+		b.WriteString("\n")
+	}
+
+	if m.insightsNaming {
+		b.WriteString("\nSave as: " + m.insightsName.View() + "\n")
+		b.WriteString(helpStyle.Render("Enter: Save • Esc: Cancel"))
+		return b.String()
+	}
+
+	b.WriteString(helpStyle.Render(
+		"Ctrl+S: Run • Ctrl+G: Log Groups • Ctrl+T: Time Range • Ctrl+P/Ctrl+N: Saved Queries • Ctrl+A: Save • Ctrl+D: Delete Saved • Esc: Back",
+	))
+	return b.String()
+}
+
+func (m Model) renderInsightsGroups() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf("🔎 Query Log Groups (%d selected)", len(m.insightsGroups))))
+	b.WriteString("\n\n")
+
+	for i, group := range m.logGroups {
+		name := aws.ToString(group.LogGroupName)
+		check := "[ ]"
+		if m.insightsGroups[name] {
+			check = "[x]"
+		}
+		line := check + " " + strings.TrimPrefix(name, "/aws/lambda/")
+		if i == m.insightsGroupCursor {
+			b.WriteString(selectedStyle.Render("▶ " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(fmt.Sprintf("↑/↓: Navigate • Space: Toggle • a: Select All (max %d) • Enter: Done", maxInsightsGroups)))
+	return b.String()
+}
+
+func (m Model) renderInsightsRunning() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf("🔎 Logs Insights: %s (%s)", m.insightsGroupsLabel(), m.timeRange.label())))
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("%s Query %s...\n", m.spinner.View(), strings.ToLower(string(m.insightsStatus))))
+	b.WriteString(m.renderInsightsStats())
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("Esc: Cancel Query"))
+	return b.String()
+}
+
+// renderInsightsStats summarizes how much the query has read so far
+func (m Model) renderInsightsStats() string {
+	if m.insightsStats == nil {
+		return ""
+	}
+	return timestampStyle.Render(fmt.Sprintf("%d results • %.0f records matched • %.0f scanned • %.1f MB scanned",
+		m.insightsMatched, m.insightsStats.RecordsMatched, m.insightsStats.RecordsScanned,
+		m.insightsStats.BytesScanned/(1024*1024))) + "\n"
+}
+
+func (m Model) renderInsightsResults() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(fmt.Sprintf("🔎 Logs Insights: %s (%s)", m.insightsGroupsLabel(), m.timeRange.label())))
+	b.WriteString("\n")
+	b.WriteString(m.renderInsightsStats())
+
+	if len(m.insightsRows) == 0 {
+		b.WriteString("\nNo results\n")
+	} else {
+		b.WriteString(m.insightsTable.View())
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓: Navigate • ←/→: Sort Column • s: Reverse Sort • r: Run Again • Esc: Back to Query"))
+	return b.String()
+}
@@ -0,0 +1,308 @@
+package dynamo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Backup files are named <timestamp>-<operation>.jsonl
+const backupTimeFormat = "20060102-150405.000"
+
+// Longest line accepted when reading a backup back (items are at most 400 KB)
+const maxBackupLine = 4 * 1024 * 1024
+
+type backupsLoadedMsg struct {
+	backups []backupInfo
+	err     error
+}
+
+// backupInfo describes one backup file of a table
+type backupInfo struct {
+	Name    string
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// backupWriter appends items to a DynamoDB JSON lines backup file
+type backupWriter struct {
+	file  *os.File
+	buf   *bufio.Writer
+	path  string
+	count int
+}
+
+// newBackupWriter creates a timestamped backup file for the selected table
+func (m Model) newBackupWriter(operation string) (*backupWriter, error) {
+	return m.newTableBackupWriter(m.selectedTable, operation)
+}
+
+// newTableBackupWriter creates a timestamped backup file for any table
+func (m Model) newTableBackupWriter(tableName, operation string) (*backupWriter, error) {
+	dir, err := m.config.GetBackupDir(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s-%s.jsonl", time.Now().Format(backupTimeFormat), operation)
+	path := filepath.Join(dir, name)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &backupWriter{file: file, buf: bufio.NewWriter(file), path: path}, nil
+}
+
+// write appends items and syncs them to disk before returning
+func (b *backupWriter) write(items []map[string]types.AttributeValue) error {
+	for _, item := range items {
+		data, err := encodeItem(item, formatDynamoJSON, false)
+		if err != nil {
+			return err
+		}
+		b.buf.Write(data)
+		if err := b.buf.WriteByte('\n'); err != nil {
+			return err
+		}
+		b.count++
+	}
+
+	if err := b.buf.Flush(); err != nil {
+		return err
+	}
+	return b.file.Sync()
+}
+
+// close finishes the backup and returns its path. Backups that never got an
+// item are removed and return an empty path.
+func (b *backupWriter) close() (string, error) {
+	if err := b.buf.Flush(); err != nil {
+		b.file.Close()
+		return "", err
+	}
+	if err := b.file.Close(); err != nil {
+		return "", err
+	}
+
+	if b.count == 0 {
+		return "", os.Remove(b.path)
+	}
+	return b.path, nil
+}
+
+// fullItems re-reads rows from the base table by key, so that partial rows
+// from an index become complete items. Rows whose item no longer exists are dropped.
+func fullItems(
+	ctx context.Context,
+	client *dynamodb.Client,
+	tableName string,
+	keySchema TableKeySchema,
+	rows []map[string]types.AttributeValue,
+) ([]map[string]types.AttributeValue, error) {
+	keys := make([]map[string]types.AttributeValue, len(rows))
+	for i, row := range rows {
+		keys[i] = itemKey(row, keySchema)
+	}
+
+	found, err := getExistingItems(ctx, client, tableName, keySchema, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read full items: %w", err)
+	}
+
+	items := make([]map[string]types.AttributeValue, 0, len(rows))
+	for _, row := range rows {
+		if item, ok := found[keyString(row, keySchema)]; ok {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// fullItemPager turns the pages of an index read into complete items of the base table
+func fullItemPager(client *dynamodb.Client, tableName string, keySchema TableKeySchema, source itemPager) itemPager {
+	return func(ctx context.Context) ([]map[string]types.AttributeValue, bool, error) {
+		rows, more, err := source(ctx)
+		if err != nil || len(rows) == 0 {
+			return rows, more, err
+		}
+		items, err := fullItems(ctx, client, tableName, keySchema, rows)
+		return items, more, err
+	}
+}
+
+// backupItems writes a complete backup of the items an edit or delete is about
+// to change and returns its path. Rows read from an index that doesn't project
+// every attribute are read again from the base table first.
+func (m Model) backupItems(operation string, items []map[string]types.AttributeValue) (string, error) {
+	if m.rowsArePartial() {
+		full, err := fullItems(context.TODO(), m.client, m.selectedTable, m.tableKeys[m.selectedTable], items)
+		if err != nil {
+			return "", fmt.Errorf("failed to back up items, nothing was changed: %w", err)
+		}
+		items = full
+	}
+
+	backup, err := m.newBackupWriter(operation)
+	if err == nil {
+		if err = backup.write(items); err != nil {
+			backup.close()
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to back up items, nothing was changed: %w", err)
+	}
+
+	path, err := backup.close()
+	if err != nil {
+		return "", fmt.Errorf("failed to back up items, nothing was changed: %w", err)
+	}
+	return path, nil
+}
+
+// loadBackups lists a table's backups, newest first
+func (m Model) loadBackups(tableName string) tea.Cmd {
+	return func() tea.Msg {
+		dir, err := m.config.GetBackupDir(tableName)
+		if err != nil {
+			return backupsLoadedMsg{err: err}
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return backupsLoadedMsg{err: err}
+		}
+
+		var backups []backupInfo
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			backups = append(backups, backupInfo{
+				Name:    entry.Name(),
+				Path:    filepath.Join(dir, entry.Name()),
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			})
+		}
+
+		// Names start with the timestamp
+		sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+		return backupsLoadedMsg{backups: backups}
+	}
+}
+
+func (m Model) handleBackupsLoaded(msg backupsLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.state = stateTableList
+		return m, nil
+	}
+
+	m.backups = msg.backups
+	m.backupCursor = 0
+	m.restoring = false
+	m.state = stateBackupList
+	return m, nil
+}
+
+func (m Model) updateBackupList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.restoring {
+		switch msg.String() {
+		case "y":
+			m.restoring = false
+			return m.startRestore(m.backups[m.backupCursor])
+		case "n", "esc":
+			m.restoring = false
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.state = stateTableList
+		return m, nil
+
+	case "up", "k":
+		if m.backupCursor > 0 {
+			m.backupCursor--
+		}
+
+	case "down", "j":
+		if m.backupCursor < len(m.backups)-1 {
+			m.backupCursor++
+		}
+
+	case "enter":
+		if len(m.backups) > 0 {
+			m.restoring = true
+		}
+	}
+
+	return m, nil
+}
+
+// backupPager reads a backup file back in pages of items
+func backupPager(file *os.File) itemPager {
+	const pageSize = 100
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBackupLine)
+	line := 0
+
+	return func(ctx context.Context) ([]map[string]types.AttributeValue, bool, error) {
+		var items []map[string]types.AttributeValue
+
+		for len(items) < pageSize {
+			if !scanner.Scan() {
+				return items, false, scanner.Err()
+			}
+			line++
+
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			item, _, err := decodeItem([]byte(text))
+			if err != nil {
+				return nil, false, fmt.Errorf("line %d: %w", line, err)
+			}
+			items = append(items, item)
+		}
+
+		return items, true, nil
+	}
+}
+
+// startRestore replays a backup into its table with BatchWriteItem puts.
+// Items that exist again with the same key are overwritten by the backup copy.
+func (m Model) startRestore(backup backupInfo) (tea.Model, tea.Cmd) {
+	file, err := os.Open(backup.Path)
+	if err != nil {
+		m.err = err
+		m.state = stateTableList
+		return m, nil
+	}
+
+	return m.startBatchJob(batchJob{
+		kind:      jobRestore,
+		tableName: m.selectedTable,
+		pager:     backupPager(file),
+		requests:  putRequests,
+		cleanup:   func() { file.Close() },
+	})
+}
@@ -0,0 +1,394 @@
+package dynamo
+
+import (
+	"cirrus/internal/config"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DynamoDB BatchWriteItem supports up to 25 items per batch
+const batchWriteSize = 25
+
+// Backoff bounds for retrying throttled or unprocessed writes
+const (
+	baseBackoff = 50 * time.Millisecond
+	maxBackoff  = 10 * time.Second
+)
+
+// rateLimiter spaces out writes so a run stays under a write-capacity budget.
+// It is shared by all workers of a run.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // Time per item, zero means unlimited
+	next     time.Time
+}
+
+func newRateLimiter(itemsPerSecond int) *rateLimiter {
+	l := &rateLimiter{}
+	if itemsPerSecond > 0 {
+		l.interval = time.Second / time.Duration(itemsPerSecond)
+	}
+	return l
+}
+
+// wait blocks until n more items may be written
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l.interval == 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	start := l.next
+	l.next = l.next.Add(l.interval * time.Duration(n))
+	l.mu.Unlock()
+
+	return sleepContext(ctx, time.Until(start))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff returns an exponential delay with full jitter for the given attempt
+func backoff(attempt int) time.Duration {
+	d := min(baseBackoff<<min(attempt, 16), maxBackoff)
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
+
+// isRetryableWriteError reports whether a failed BatchWriteItem call is worth retrying
+func isRetryableWriteError(err error) bool {
+	return retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary ||
+		retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary
+}
+
+// batchWrite sends up to 25 write requests, retrying UnprocessedItems and
+// throttling errors with backoff. It returns how many requests were written;
+// onRetry is called before every retry.
+func batchWrite(
+	ctx context.Context,
+	client *dynamodb.Client,
+	tableName string,
+	requests []types.WriteRequest,
+	limiter *rateLimiter,
+	maxRetries int,
+	onRetry func(),
+) (int, error) {
+	written := 0
+	pending := requests
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(ctx, len(pending)); err != nil {
+			return written, err
+		}
+
+		result, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{tableName: pending},
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return written, ctx.Err()
+			}
+			if !isRetryableWriteError(err) || attempt >= maxRetries {
+				return written, fmt.Errorf("failed to write batch: %w", err)
+			}
+		} else {
+			unprocessed := result.UnprocessedItems[tableName]
+			written += len(pending) - len(unprocessed)
+			if len(unprocessed) == 0 {
+				return written, nil
+			}
+			if attempt >= maxRetries {
+				return written, fmt.Errorf("%d items still unprocessed after %d retries", len(unprocessed), maxRetries)
+			}
+			pending = unprocessed
+		}
+
+		if onRetry != nil {
+			onRetry()
+		}
+		if err := sleepContext(ctx, backoff(attempt)); err != nil {
+			return written, err
+		}
+	}
+}
+
+// deleteRequests builds DeleteRequests for the primary keys of the given items
+func deleteRequests(items []map[string]types.AttributeValue, keys TableKeySchema) []types.WriteRequest {
+	requests := make([]types.WriteRequest, 0, len(items))
+	for _, item := range items {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{Key: itemKey(item, keys)},
+		})
+	}
+	return requests
+}
+
+// putRequests builds PutRequests for whole items
+func putRequests(items []map[string]types.AttributeValue) []types.WriteRequest {
+	requests := make([]types.WriteRequest, 0, len(items))
+	for _, item := range items {
+		requests = append(requests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
+	}
+	return requests
+}
+
+// batchJobKind says what a background batch job does, which decides its
+// progress title and where the UI goes when it finishes
+type batchJobKind int
+
+const (
+	jobEmptyTable batchJobKind = iota
+	jobDeleteMatching
+	jobRestore
+	jobImport
+	jobCopy
+)
+
+// itemPager returns the next page of items for a batch job; more is false after the last page
+type itemPager func(ctx context.Context) (items []map[string]types.AttributeValue, more bool, err error)
+
+// batchJob describes a background BatchWriteItem run over every page of a pager
+type batchJob struct {
+	kind      batchJobKind
+	tableName string
+	total     int // Expected number of items, 0 if unknown
+	pager     itemPager
+	requests  func(items []map[string]types.AttributeValue) []types.WriteRequest
+	backup    *backupWriter // Optional, receives every page before it is written
+	cleanup   func()        // Optional, called once the job has stopped
+
+	// Optional, picks what of each page is backed up instead of the whole page
+	backupOf func(ctx context.Context, items []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error)
+}
+
+type batchProgressMsg struct {
+	written  int
+	scanned  int // Items read from the pager so far
+	retries  int
+	scanDone bool // Scanned is the final total
+}
+
+type batchDoneMsg struct {
+	written    int
+	retries    int
+	cancelled  bool
+	backupPath string
+	backupErr  error // The backup may be incomplete, even if the job succeeded
+	err        error
+}
+
+// startBatchJob runs a batch job in the background and listens for its progress
+func (m Model) startBatchJob(job batchJob) (tea.Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan tea.Msg, 1)
+
+	m.batchJob = job
+	m.batchCancel = cancel
+	m.batchCancelled = false
+	m.batchUpdates = updates
+	m.batchProgress = batchProgressMsg{}
+	m.batchStarted = time.Now()
+	m.state = stateBatchRunning
+
+	client := m.client
+	cfg := m.config.GetBatchWriteConfig()
+	return m, tea.Batch(
+		func() tea.Msg {
+			runBatchJob(ctx, client, cfg, job, updates)
+			return nil
+		},
+		waitForUpdate(updates),
+	)
+}
+
+// waitForUpdate delivers the next message a background job sends on its updates channel
+func waitForUpdate(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// runBatchJob reads pages from the job's pager in one goroutine and hands out
+// batches of 25 to a pool of workers, which write them within the configured
+// write-capacity budget. Progress is reported a few times a second until a
+// batchDoneMsg closes the stream.
+func runBatchJob(
+	ctx context.Context,
+	client *dynamodb.Client,
+	cfg config.BatchWriteConfig,
+	job batchJob,
+	updates chan<- tea.Msg,
+) {
+	if job.cleanup != nil {
+		defer job.cleanup()
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limiter := newRateLimiter(cfg.WriteCapacity)
+	var written, scanned, retries atomic.Int64
+	var scanDone atomic.Bool
+
+	var errOnce sync.Once
+	var firstErr error
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	backup := job.backup
+	var wg sync.WaitGroup
+	batches := make(chan []map[string]types.AttributeValue, cfg.Workers)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(batches)
+
+		for runCtx.Err() == nil {
+			items, more, err := job.pager(runCtx)
+			if err != nil {
+				if runCtx.Err() == nil {
+					fail(fmt.Errorf("failed to read items: %w", err))
+				}
+				return
+			}
+			scanned.Add(int64(len(items)))
+
+			// Nothing is written before it is safely in the backup
+			if backup != nil {
+				backedUp := items
+				if job.backupOf != nil {
+					if backedUp, err = job.backupOf(runCtx, items); err != nil {
+						fail(fmt.Errorf("failed to read items to back up: %w", err))
+						return
+					}
+				}
+				if err := backup.write(backedUp); err != nil {
+					fail(fmt.Errorf("failed to write backup: %w", err))
+					return
+				}
+			}
+
+			for i := 0; i < len(items); i += batchWriteSize {
+				select {
+				case batches <- items[i:min(i+batchWriteSize, len(items))]:
+				case <-runCtx.Done():
+					return
+				}
+			}
+
+			if !more {
+				scanDone.Store(true)
+				return
+			}
+		}
+	}()
+
+	for range cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				n, err := batchWrite(runCtx, client, job.tableName, job.requests(batch), limiter, cfg.MaxRetries, func() {
+					retries.Add(1)
+				})
+				written.Add(int64(n))
+				if err != nil && runCtx.Err() == nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			progress := batchProgressMsg{
+				written:  int(written.Load()),
+				scanned:  int(scanned.Load()),
+				retries:  int(retries.Load()),
+				scanDone: scanDone.Load(),
+			}
+			// Drop the update if the UI hasn't taken the previous one yet
+			select {
+			case updates <- progress:
+			default:
+			}
+
+		case <-done:
+			msg := batchDoneMsg{
+				written:   int(written.Load()),
+				retries:   int(retries.Load()),
+				cancelled: firstErr == nil && ctx.Err() != nil,
+				err:       firstErr,
+			}
+			if backup != nil {
+				if path, err := backup.close(); err != nil {
+					msg.backupErr = fmt.Errorf("failed to close backup: %w", err)
+				} else {
+					msg.backupPath = path
+				}
+			}
+			updates <- msg
+			close(updates)
+			return
+		}
+	}
+}
+
+func (m Model) updateBatchRunning(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "x":
+		// The job stops after its in-flight batches and reports what it wrote
+		if m.batchCancel != nil && !m.batchCancelled {
+			m.batchCancel()
+			m.batchCancelled = true
+		}
+	}
+	return m, nil
+}
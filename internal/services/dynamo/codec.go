@@ -0,0 +1,377 @@
+package dynamo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Item encoding formats
+const (
+	formatDynamoJSON = "dynamodb-json" // {"name": {"S": "x"}, "age": {"N": "3"}}
+	formatPlainJSON  = "json"          // {"name": "x", "age": 3}
+)
+
+// attributeValueToDynamoJSON converts an AttributeValue into its typed DynamoDB JSON form
+func attributeValueToDynamoJSON(av types.AttributeValue) any {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return map[string]any{"S": v.Value}
+	case *types.AttributeValueMemberN:
+		return map[string]any{"N": v.Value}
+	case *types.AttributeValueMemberBOOL:
+		return map[string]any{"BOOL": v.Value}
+	case *types.AttributeValueMemberNULL:
+		return map[string]any{"NULL": true}
+	case *types.AttributeValueMemberB:
+		return map[string]any{"B": base64.StdEncoding.EncodeToString(v.Value)}
+	case *types.AttributeValueMemberSS:
+		return map[string]any{"SS": v.Value}
+	case *types.AttributeValueMemberNS:
+		return map[string]any{"NS": v.Value}
+	case *types.AttributeValueMemberBS:
+		var values []string
+		for _, b := range v.Value {
+			values = append(values, base64.StdEncoding.EncodeToString(b))
+		}
+		return map[string]any{"BS": values}
+	case *types.AttributeValueMemberL:
+		list := make([]any, 0, len(v.Value))
+		for _, item := range v.Value {
+			list = append(list, attributeValueToDynamoJSON(item))
+		}
+		return map[string]any{"L": list}
+	case *types.AttributeValueMemberM:
+		m := make(map[string]any, len(v.Value))
+		for k, item := range v.Value {
+			m[k] = attributeValueToDynamoJSON(item)
+		}
+		return map[string]any{"M": m}
+	}
+	return map[string]any{"NULL": true}
+}
+
+// hasTypedValues reports whether an item holds sets or binary values, which
+// plain JSON can't tell apart from lists and strings
+func hasTypedValues(item map[string]types.AttributeValue) bool {
+	for _, av := range item {
+		if isTypedValue(av) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTypedValue(av types.AttributeValue) bool {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberB, *types.AttributeValueMemberBS,
+		*types.AttributeValueMemberSS, *types.AttributeValueMemberNS:
+		return true
+	case *types.AttributeValueMemberL:
+		for _, elem := range v.Value {
+			if isTypedValue(elem) {
+				return true
+			}
+		}
+	case *types.AttributeValueMemberM:
+		return hasTypedValues(v.Value)
+	}
+	return false
+}
+
+// attributeValueToPlain converts an AttributeValue into plain JSON values.
+// Binary values become base64 strings and sets become lists.
+func attributeValueToPlain(av types.AttributeValue) any {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value
+	case *types.AttributeValueMemberN:
+		return json.Number(v.Value)
+	case *types.AttributeValueMemberBOOL:
+		return v.Value
+	case *types.AttributeValueMemberNULL:
+		return nil
+	case *types.AttributeValueMemberB:
+		return base64.StdEncoding.EncodeToString(v.Value)
+	case *types.AttributeValueMemberSS:
+		return v.Value
+	case *types.AttributeValueMemberNS:
+		var values []json.Number
+		for _, n := range v.Value {
+			values = append(values, json.Number(n))
+		}
+		return values
+	case *types.AttributeValueMemberBS:
+		var values []string
+		for _, b := range v.Value {
+			values = append(values, base64.StdEncoding.EncodeToString(b))
+		}
+		return values
+	case *types.AttributeValueMemberL:
+		list := make([]any, 0, len(v.Value))
+		for _, item := range v.Value {
+			list = append(list, attributeValueToPlain(item))
+		}
+		return list
+	case *types.AttributeValueMemberM:
+		m := make(map[string]any, len(v.Value))
+		for k, item := range v.Value {
+			m[k] = attributeValueToPlain(item)
+		}
+		return m
+	}
+	return nil
+}
+
+// encodeItem renders an item as indented JSON in the given format
+func encodeItem(item map[string]types.AttributeValue, format string, indent bool) ([]byte, error) {
+	out := make(map[string]any, len(item))
+	for k, av := range item {
+		if format == formatDynamoJSON {
+			out[k] = attributeValueToDynamoJSON(av)
+		} else {
+			out[k] = attributeValueToPlain(av)
+		}
+	}
+
+	if indent {
+		return json.MarshalIndent(out, "", "  ")
+	}
+	return json.Marshal(out)
+}
+
+// decodeItem parses an item from DynamoDB JSON or plain JSON, detecting which one it is
+func decodeItem(data []byte) (map[string]types.AttributeValue, string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, "", fmt.Errorf("invalid JSON: %w", err)
+	}
+	if dec.More() {
+		return nil, "", fmt.Errorf("invalid JSON: more than one object")
+	}
+
+	if isDynamoJSON(raw) {
+		item, err := dynamoJSONToItem(raw)
+		return item, formatDynamoJSON, err
+	}
+
+	item := make(map[string]types.AttributeValue, len(raw))
+	for k, v := range raw {
+		item[k] = plainToAttributeValue(v)
+	}
+	return item, formatPlainJSON, nil
+}
+
+func dynamoJSONToItem(raw map[string]any) (map[string]types.AttributeValue, error) {
+	item := make(map[string]types.AttributeValue, len(raw))
+	for k, v := range raw {
+		av, err := dynamoJSONToAttributeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		item[k] = av
+	}
+	return item, nil
+}
+
+var typeDescriptors = map[string]bool{
+	"S": true, "N": true, "B": true, "BOOL": true, "NULL": true,
+	"SS": true, "NS": true, "BS": true, "L": true, "M": true,
+}
+
+// isDynamoJSON reports whether every attribute is a single-key type descriptor object
+func isDynamoJSON(raw map[string]any) bool {
+	if len(raw) == 0 {
+		return false
+	}
+	for _, v := range raw {
+		obj, ok := v.(map[string]any)
+		if !ok || len(obj) != 1 {
+			return false
+		}
+		for t := range obj {
+			if !typeDescriptors[t] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func dynamoJSONToAttributeValue(v any) (types.AttributeValue, error) {
+	obj, ok := v.(map[string]any)
+	if !ok || len(obj) != 1 {
+		return nil, fmt.Errorf("expected an object with one type descriptor")
+	}
+
+	for t, value := range obj {
+		switch t {
+		case "S":
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("S must be a string")
+			}
+			return &types.AttributeValueMemberS{Value: s}, nil
+
+		case "N":
+			n, err := numberString(value)
+			if err != nil {
+				return nil, err
+			}
+			return &types.AttributeValueMemberN{Value: n}, nil
+
+		case "BOOL":
+			b, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("BOOL must be true or false")
+			}
+			return &types.AttributeValueMemberBOOL{Value: b}, nil
+
+		case "NULL":
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+
+		case "B":
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("B must be a base64 string")
+			}
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, fmt.Errorf("B must be a base64 string")
+			}
+			return &types.AttributeValueMemberB{Value: b}, nil
+
+		case "SS", "NS", "BS":
+			list, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list", t)
+			}
+			var values []string
+			for _, item := range list {
+				var s string
+				var err error
+				if t == "NS" {
+					s, err = numberString(item)
+				} else if s, ok = item.(string); !ok {
+					err = fmt.Errorf("%s must contain strings", t)
+				}
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, s)
+			}
+
+			switch t {
+			case "SS":
+				return &types.AttributeValueMemberSS{Value: values}, nil
+			case "NS":
+				return &types.AttributeValueMemberNS{Value: values}, nil
+			}
+			var blobs [][]byte
+			for _, s := range values {
+				b, err := base64.StdEncoding.DecodeString(s)
+				if err != nil {
+					return nil, fmt.Errorf("BS must contain base64 strings")
+				}
+				blobs = append(blobs, b)
+			}
+			return &types.AttributeValueMemberBS{Value: blobs}, nil
+
+		case "L":
+			list, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("L must be a list")
+			}
+			values := make([]types.AttributeValue, 0, len(list))
+			for i, item := range list {
+				av, err := dynamoJSONToAttributeValue(item)
+				if err != nil {
+					return nil, fmt.Errorf("[%d]: %w", i, err)
+				}
+				values = append(values, av)
+			}
+			return &types.AttributeValueMemberL{Value: values}, nil
+
+		case "M":
+			m, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("M must be an object")
+			}
+			values, err := dynamoJSONToItem(m)
+			if err != nil {
+				return nil, err
+			}
+			return &types.AttributeValueMemberM{Value: values}, nil
+
+		default:
+			return nil, fmt.Errorf("unknown type descriptor %q", t)
+		}
+	}
+	return nil, fmt.Errorf("expected a type descriptor")
+}
+
+func numberString(v any) (string, error) {
+	switch n := v.(type) {
+	case string:
+		if _, err := json.Number(n).Float64(); err != nil {
+			return "", fmt.Errorf("%q is not a number", n)
+		}
+		return n, nil
+	case json.Number:
+		return n.String(), nil
+	}
+	return "", fmt.Errorf("N must be a number")
+}
+
+// plainToAttributeValue infers DynamoDB types from plain JSON values
+func plainToAttributeValue(v any) types.AttributeValue {
+	switch val := v.(type) {
+	case nil:
+		return &types.AttributeValueMemberNULL{Value: true}
+	case string:
+		return &types.AttributeValueMemberS{Value: val}
+	case json.Number:
+		return &types.AttributeValueMemberN{Value: val.String()}
+	case float64:
+		return &types.AttributeValueMemberN{Value: fmt.Sprint(val)}
+	case bool:
+		return &types.AttributeValueMemberBOOL{Value: val}
+	case []any:
+		list := make([]types.AttributeValue, 0, len(val))
+		for _, item := range val {
+			list = append(list, plainToAttributeValue(item))
+		}
+		return &types.AttributeValueMemberL{Value: list}
+	case map[string]any:
+		m := make(map[string]types.AttributeValue, len(val))
+		for k, item := range val {
+			m[k] = plainToAttributeValue(item)
+		}
+		return &types.AttributeValueMemberM{Value: m}
+	}
+	return &types.AttributeValueMemberS{Value: fmt.Sprint(v)}
+}
+
+// attributeValuesEqual compares two AttributeValues by their DynamoDB JSON encoding
+func attributeValuesEqual(a, b types.AttributeValue) bool {
+	aj, err1 := json.Marshal(attributeValueToDynamoJSON(a))
+	bj, err2 := json.Marshal(attributeValueToDynamoJSON(b))
+	return err1 == nil && err2 == nil && bytes.Equal(aj, bj)
+}
+
+// sortedKeys returns an item's attribute names in order
+func sortedKeys(item map[string]types.AttributeValue) []string {
+	keys := make([]string, 0, len(item))
+	for k := range item {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
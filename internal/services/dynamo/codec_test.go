@@ -0,0 +1,211 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestDynamoJSONRoundTrip(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"pk":    avS("user#1"),
+		"age":   avN("42.5"),
+		"ok":    &types.AttributeValueMemberBOOL{Value: false},
+		"gone":  &types.AttributeValueMemberNULL{Value: true},
+		"blob":  &types.AttributeValueMemberB{Value: []byte{0, 1, 2, 255}},
+		"tags":  &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"nums":  &types.AttributeValueMemberNS{Value: []string{"1", "-2.5e3"}},
+		"blobs": &types.AttributeValueMemberBS{Value: [][]byte{{1}, {2, 3}}},
+		"list": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			avS("x"),
+			&types.AttributeValueMemberSS{Value: []string{"y"}},
+			&types.AttributeValueMemberNULL{Value: true},
+		}},
+		"doc": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			// Looks like a type descriptor, but is a map key
+			"S":     avS("not a string descriptor"),
+			"inner": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}},
+		}},
+	}
+
+	for _, indent := range []bool{false, true} {
+		data, err := encodeItem(item, formatDynamoJSON, indent)
+		if err != nil {
+			t.Fatalf("encodeItem failed: %v", err)
+		}
+		got, format, err := decodeItem(data)
+		if err != nil {
+			t.Fatalf("decodeItem(%s) failed: %v", data, err)
+		}
+		if format != formatDynamoJSON {
+			t.Errorf("decodeItem(%s) format = %q, want %q", data, format, formatDynamoJSON)
+		}
+		if !reflect.DeepEqual(got, item) {
+			t.Errorf("round trip changed the item:\n got %#v\nwant %#v", got, item)
+		}
+	}
+}
+
+func TestPlainJSONRoundTrip(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"pk":   avS("user#1"),
+		"age":  avN("42"),
+		"ok":   &types.AttributeValueMemberBOOL{Value: true},
+		"gone": &types.AttributeValueMemberNULL{Value: true},
+		"list": &types.AttributeValueMemberL{Value: []types.AttributeValue{avN("1"), avS("x")}},
+		"doc": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"S": avS("x"),
+			"M": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"a": avN("1")}},
+		}},
+	}
+	if hasTypedValues(item) {
+		t.Fatal("hasTypedValues = true for an item plain JSON can hold")
+	}
+
+	data, err := encodeItem(item, formatPlainJSON, false)
+	if err != nil {
+		t.Fatalf("encodeItem failed: %v", err)
+	}
+	got, format, err := decodeItem(data)
+	if err != nil {
+		t.Fatalf("decodeItem(%s) failed: %v", data, err)
+	}
+	if format != formatPlainJSON {
+		t.Errorf("decodeItem(%s) format = %q, want %q", data, format, formatPlainJSON)
+	}
+	if !reflect.DeepEqual(got, item) {
+		t.Errorf("round trip changed the item:\n got %#v\nwant %#v", got, item)
+	}
+}
+
+func TestPlainJSONTypedValues(t *testing.T) {
+	// Plain JSON can't hold sets and binary values, they come back as lists and strings
+	item := map[string]types.AttributeValue{
+		"pk":    avS("1"),
+		"blob":  &types.AttributeValueMemberB{Value: []byte{1, 2}},
+		"tags":  &types.AttributeValueMemberSS{Value: []string{"a"}},
+		"nums":  &types.AttributeValueMemberNS{Value: []string{"7"}},
+		"blobs": &types.AttributeValueMemberBS{Value: [][]byte{{1}}},
+	}
+	want := map[string]types.AttributeValue{
+		"pk":    avS("1"),
+		"blob":  avS("AQI="),
+		"tags":  &types.AttributeValueMemberL{Value: []types.AttributeValue{avS("a")}},
+		"nums":  &types.AttributeValueMemberL{Value: []types.AttributeValue{avN("7")}},
+		"blobs": &types.AttributeValueMemberL{Value: []types.AttributeValue{avS("AQ==")}},
+	}
+
+	for name, av := range item {
+		if name != "pk" && !isTypedValue(av) {
+			t.Errorf("isTypedValue(%s) = false", name)
+		}
+	}
+	nested := map[string]types.AttributeValue{
+		"doc": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"list": &types.AttributeValueMemberL{Value: []types.AttributeValue{item["blob"]}},
+		}},
+	}
+	if !hasTypedValues(nested) {
+		t.Error("hasTypedValues = false for a binary value nested in a map and list")
+	}
+
+	data, err := encodeItem(item, formatPlainJSON, false)
+	if err != nil {
+		t.Fatalf("encodeItem failed: %v", err)
+	}
+	got, _, err := decodeItem(data)
+	if err != nil {
+		t.Fatalf("decodeItem(%s) failed: %v", data, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeItem(%s) = %#v, want %#v", data, got, want)
+	}
+}
+
+func TestDecodeItemFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantFormat string
+		want       map[string]types.AttributeValue
+	}{
+		{
+			name:       "plain object whose only key is S",
+			input:      `{"S": "hello"}`,
+			wantFormat: formatPlainJSON,
+			want:       map[string]types.AttributeValue{"S": avS("hello")},
+		},
+		{
+			name:       "plain object whose only key is M",
+			input:      `{"M": {"a": 1}}`,
+			wantFormat: formatPlainJSON,
+			want: map[string]types.AttributeValue{
+				"M": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"a": avN("1")}},
+			},
+		},
+		{
+			name:       "descriptor-like map next to a plain value",
+			input:      `{"pk": "1", "doc": {"S": "x"}}`,
+			wantFormat: formatPlainJSON,
+			want: map[string]types.AttributeValue{
+				"pk":  avS("1"),
+				"doc": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"S": avS("x")}},
+			},
+		},
+		{
+			name:       "every attribute a type descriptor",
+			input:      `{"M": {"M": {"S": {"S": "x"}}}}`,
+			wantFormat: formatDynamoJSON,
+			want: map[string]types.AttributeValue{
+				"M": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"S": avS("x")}},
+			},
+		},
+		{
+			name:       "numbers keep their text",
+			input:      `{"pk": {"S": "1"}, "n": {"N": 1.50}, "ns": {"NS": ["2", 3]}}`,
+			wantFormat: formatDynamoJSON,
+			want: map[string]types.AttributeValue{
+				"pk": avS("1"),
+				"n":  avN("1.50"),
+				"ns": &types.AttributeValueMemberNS{Value: []string{"2", "3"}},
+			},
+		},
+		{
+			name:       "empty object",
+			input:      `{}`,
+			wantFormat: formatPlainJSON,
+			want:       map[string]types.AttributeValue{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, format, err := decodeItem([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("decodeItem failed: %v", err)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeItem(%s) = %#v, want %#v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	for _, input := range []string{
+		`not json`,
+		`{"a": 1} {"b": 2}`,
+		`{"n": {"N": "ten"}}`,
+		`{"b": {"B": "!!"}}`,
+		`{"s": {"SS": "a"}}`,
+		`{"s": {"SS": [1]}}`,
+		`{"bs": {"BS": ["!!"]}}`,
+		`{"l": {"L": [{"X": 1}]}}`,
+	} {
+		if _, _, err := decodeItem([]byte(input)); err == nil {
+			t.Errorf("decodeItem(%s) succeeded, want an error", input)
+		}
+	}
+}
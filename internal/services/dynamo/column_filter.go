@@ -2,8 +2,10 @@ package dynamo
 
 import (
 	"cirrus/internal/styles"
+	"slices"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -13,6 +15,11 @@ type ColumnFilterModel struct {
 	cursorIdx        int
 	Width            int
 	Height           int
+
+	// Nested document paths added as virtual columns
+	pathInput  textinput.Model
+	addingPath bool
+	err        error
 }
 
 type ColumnFilterSavedMsg struct {
@@ -27,6 +34,13 @@ func NewColumnFilterModel(available []string, selected []string) ColumnFilterMod
 		for _, col := range selected {
 			selectedMap[col] = true
 		}
+
+		// Saved virtual columns aren't top-level keys of the items
+		for _, col := range selected {
+			if isNestedPath(col) && !slices.Contains(available, col) {
+				available = append(available, col)
+			}
+		}
 	} else {
 		// By default, select all columns
 		for _, col := range available {
@@ -34,11 +48,54 @@ func NewColumnFilterModel(available []string, selected []string) ColumnFilterMod
 		}
 	}
 
+	pathInput := textinput.New()
+	pathInput.Placeholder = "Nested path (e.g., payload.customer.id or events[0].type)"
+	pathInput.Width = 60
+
 	return ColumnFilterModel{
 		availableColumns: available,
 		selectedColumns:  selectedMap,
 		cursorIdx:        0,
+		pathInput:        pathInput,
+	}
+}
+
+// IsEditing reports whether the path input has focus and should receive all keys
+func (m ColumnFilterModel) IsEditing() bool {
+	return m.addingPath
+}
+
+func (m ColumnFilterModel) updatePathInput(msg tea.KeyMsg) (ColumnFilterModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.addingPath = false
+		m.err = nil
+		m.pathInput.SetValue("")
+		return m, nil
+
+	case "enter":
+		path := strings.TrimSpace(m.pathInput.Value())
+		if _, err := parseAttributePath(path); err != nil {
+			m.err = err
+			return m, nil
+		}
+
+		if !slices.Contains(m.availableColumns, path) {
+			m.availableColumns = append(m.availableColumns, path)
+		}
+		m.selectedColumns[path] = true
+		m.cursorIdx = len(m.availableColumns) - 1
+
+		m.addingPath = false
+		m.err = nil
+		m.pathInput.SetValue("")
+		m.pathInput.Blur()
+		return m, nil
 	}
+
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	return m, cmd
 }
 
 func (m ColumnFilterModel) Init() tea.Cmd {
@@ -52,7 +109,15 @@ func (m ColumnFilterModel) Update(msg tea.Msg) (ColumnFilterModel, tea.Cmd) {
 		m.Height = msg.Height
 
 	case tea.KeyMsg:
+		if m.addingPath {
+			return m.updatePathInput(msg)
+		}
+
 		switch msg.String() {
+		case "+":
+			// Add a nested path as a virtual column
+			m.addingPath = true
+			return m, m.pathInput.Focus()
 		case "up", "k":
 			if m.cursorIdx > 0 {
 				m.cursorIdx--
@@ -63,6 +128,9 @@ func (m ColumnFilterModel) Update(msg tea.Msg) (ColumnFilterModel, tea.Cmd) {
 			}
 		case " ", "enter":
 			// Toggle selection
+			if len(m.availableColumns) == 0 {
+				break
+			}
 			col := m.availableColumns[m.cursorIdx]
 			m.selectedColumns[col] = !m.selectedColumns[col]
 		case "a":
@@ -100,6 +168,9 @@ func (m ColumnFilterModel) View() string {
 		}
 
 		line := cursor + checkbox + " " + col
+		if isNestedPath(col) {
+			line += styles.TypeStyle.Render(" (nested)")
+		}
 
 		if i == m.cursorIdx {
 			b.WriteString(styles.SelectedStyle.Render(line))
@@ -109,10 +180,22 @@ func (m ColumnFilterModel) View() string {
 		b.WriteString("\n")
 	}
 
+	if m.addingPath {
+		b.WriteString("\n")
+		b.WriteString(m.pathInput.View())
+		b.WriteString("\n")
+		if m.err != nil {
+			b.WriteString(styles.ErrorStyle.Render(m.err.Error()))
+			b.WriteString("\n")
+		}
+		b.WriteString(styles.HelpStyle.Render("Enter: Add column • Esc: Cancel"))
+		return b.String()
+	}
+
 	b.WriteString("\n")
 	b.WriteString(
 		styles.HelpStyle.Render(
-			"↑/↓: Navigate • Space/Enter: Toggle • a: All • n: None • s: Save • Esc: Cancel",
+			"↑/↓: Navigate • Space/Enter: Toggle • a: All • n: None • +: Add Nested Path • s: Save • Esc: Cancel",
 		),
 	)
 
@@ -1,10 +1,14 @@
 package dynamo
 
 import (
+	"cirrus/internal/config"
 	"cirrus/internal/services/dynamo/filter"
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -21,33 +25,32 @@ type tablesLoadedMsg struct {
 type tableKeysLoadedMsg struct {
 	tableName string
 	keys      TableKeySchema
+	indexes   []TableIndex
 	err       error
 }
 
-type itemsLoadedMsg struct {
-	items []map[string]types.AttributeValue
+type itemsCountedMsg struct {
+	count int
 	err   error
 }
 
-type itemDeletedMsg struct {
-	err error
+type itemsLoadedMsg struct {
+	items            []map[string]types.AttributeValue
+	lastEvaluatedKey map[string]types.AttributeValue
+	nextToken        *string // PartiQL continuation token
+	appendPage       bool    // Items continue the current result set
+	err              error
 }
 
 func (m Model) loadTables() tea.Cmd {
 	return func() tea.Msg {
-		result, err := m.client.ListTables(context.TODO(), &dynamodb.ListTablesInput{})
+		matcher, err := m.config.GetTableDiscovery(m.env).Compile(m.env)
 		if err != nil {
 			return tablesLoadedMsg{err: err}
 		}
 
-		var filteredTables []string
-		for _, table := range result.TableNames {
-			if strings.HasPrefix(table, "dev-cot") && strings.HasSuffix(table, m.env) {
-				filteredTables = append(filteredTables, table)
-			}
-		}
-
-		return tablesLoadedMsg{tables: filteredTables}
+		tables, err := discoverTables(m.client, []*config.NameMatcher{matcher})
+		return tablesLoadedMsg{tables: tables, err: err}
 	}
 }
 
@@ -60,79 +63,691 @@ func (m Model) loadTableKeys(tableName string) tea.Cmd {
 			return tableKeysLoadedMsg{tableName: tableName, err: err}
 		}
 
-		keys := TableKeySchema{}
-		for _, key := range result.Table.KeySchema {
-			if key.KeyType == types.KeyTypeHash {
-				keys.PartitionKey = *key.AttributeName
-			} else if key.KeyType == types.KeyTypeRange {
-				keys.SortKey = *key.AttributeName
-			}
-		}
-
 		return tableKeysLoadedMsg{
 			tableName: tableName,
-			keys:      keys,
+			keys:      keySchemaFromDescription(result.Table.KeySchema, result.Table.AttributeDefinitions),
+			indexes:   indexesFromDescription(result.Table),
+		}
+	}
+}
+
+// indexesFromDescription lists a table's global and local secondary indexes
+func indexesFromDescription(table *types.TableDescription) []TableIndex {
+	var indexes []TableIndex
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		indexes = append(indexes, TableIndex{
+			Name:       aws.ToString(gsi.IndexName),
+			Global:     true,
+			Keys:       keySchemaFromDescription(gsi.KeySchema, table.AttributeDefinitions),
+			Projection: projectionFromDescription(gsi.Projection),
+		})
+	}
+	for _, lsi := range table.LocalSecondaryIndexes {
+		indexes = append(indexes, TableIndex{
+			Name:       aws.ToString(lsi.IndexName),
+			Keys:       keySchemaFromDescription(lsi.KeySchema, table.AttributeDefinitions),
+			Projection: projectionFromDescription(lsi.Projection),
+		})
+	}
+	return indexes
+}
+
+func keySchemaFromDescription(
+	schema []types.KeySchemaElement,
+	attrDefs []types.AttributeDefinition,
+) TableKeySchema {
+	keys := TableKeySchema{}
+	for _, key := range schema {
+		if key.KeyType == types.KeyTypeHash {
+			keys.PartitionKey = *key.AttributeName
+		} else if key.KeyType == types.KeyTypeRange {
+			keys.SortKey = *key.AttributeName
+		}
+	}
+
+	// Key types are needed to build key condition values
+	for _, def := range attrDefs {
+		switch aws.ToString(def.AttributeName) {
+		case keys.PartitionKey:
+			keys.PartitionKeyType = def.AttributeType
+		case keys.SortKey:
+			keys.SortKeyType = def.AttributeType
 		}
 	}
+
+	return keys
+}
+
+func projectionFromDescription(p *types.Projection) string {
+	if p == nil {
+		return ""
+	}
+	if p.ProjectionType == types.ProjectionTypeInclude {
+		return fmt.Sprintf("INCLUDE (%s)", strings.Join(p.NonKeyAttributes, ", "))
+	}
+	return string(p.ProjectionType)
+}
+
+// readParams describes the result set shown in the item list
+type readParams struct {
+	TableName string
+	IndexName string // Empty reads the base table
+	Keys      TableKeySchema
+	Query     *KeyQuery // nil reads the table with Scan
+	Filters   []filter.FilterCondition
+}
+
+func (m Model) readParams(filters []filter.FilterCondition) readParams {
+	return readParams{
+		TableName: m.selectedTable,
+		IndexName: m.selectedIndex,
+		Keys:      m.activeKeys(),
+		Query:     m.activeQuery,
+		Filters:   resolveFilterTypes(filters, m.columnTypes, m.items),
+	}
 }
 
 func (m *Model) loadItems(filters []filter.FilterCondition) tea.Cmd {
 	return func() tea.Msg {
-		input := &dynamodb.ScanInput{
-			TableName: aws.String(m.selectedTable),
+		items, lastKey, err := readPage(m.client, m.readParams(filters), nil)
+		if err != nil {
+			return itemsLoadedMsg{err: err}
+		}
+
+		return itemsLoadedMsg{
+			items:            items,
+			lastEvaluatedKey: lastKey,
 		}
+	}
+}
 
-		// Build FilterExpression from conditions
-		if len(filters) > 0 {
-			filterExpr, exprAttrNames, exprAttrValues := buildFilterExpression(filters)
+// loadNextPage continues the current result set from the stored LastEvaluatedKey
+func (m Model) loadNextPage() tea.Cmd {
+	params := m.readParams(m.activeFilters)
+	startKey := m.lastEvaluatedKey
+
+	return func() tea.Msg {
+		items, lastKey, err := readPage(m.client, params, startKey)
+		if err != nil {
+			return itemsLoadedMsg{err: err}
+		}
+
+		return itemsLoadedMsg{
+			items:            items,
+			lastEvaluatedKey: lastKey,
+			appendPage:       true,
+		}
+	}
+}
+
+// loadRemainingPages reads every page left in the current result set
+func (m Model) loadRemainingPages() tea.Cmd {
+	params := m.readParams(m.activeFilters)
+	startKey := m.lastEvaluatedKey
+
+	return func() tea.Msg {
+		items, err := readAllPages(m.client, params, startKey)
+		if err != nil {
+			return itemsLoadedMsg{err: err}
+		}
+
+		return itemsLoadedMsg{
+			items:      items,
+			appendPage: true,
+		}
+	}
+}
+
+// countItems counts the items of a table with a COUNT scan, which reads every
+// item but returns none of them
+func (m Model) countItems(tableName string) tea.Cmd {
+	return func() tea.Msg {
+		count := 0
+		var startKey map[string]types.AttributeValue
+		for {
+			result, err := m.client.Scan(context.TODO(), &dynamodb.ScanInput{
+				TableName:         aws.String(tableName),
+				Select:            types.SelectCount,
+				ExclusiveStartKey: startKey,
+			})
+			if err != nil {
+				return itemsCountedMsg{err: err}
+			}
+			count += int(result.Count)
+
+			if len(result.LastEvaluatedKey) == 0 {
+				return itemsCountedMsg{count: count}
+			}
+			startKey = result.LastEvaluatedKey
+		}
+	}
+}
+
+// readPage runs a single Scan or Query call depending on the params
+func readPage(
+	client *dynamodb.Client,
+	p readParams,
+	startKey map[string]types.AttributeValue,
+) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	if p.Query == nil {
+		input, err := buildScanInput(p.TableName, p.Filters, startKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if p.IndexName != "" {
+			input.IndexName = aws.String(p.IndexName)
+		}
+		result, err := client.Scan(context.TODO(), input)
+		if err != nil {
+			return nil, nil, err
+		}
+		return applyClientFilters(result.Items, p.Filters), result.LastEvaluatedKey, nil
+	}
+
+	input, err := buildQueryInput(p, startKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := client.Query(context.TODO(), input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return applyClientFilters(result.Items, p.Filters), result.LastEvaluatedKey, nil
+}
+
+// readAllPages reads from startKey until DynamoDB stops returning a LastEvaluatedKey
+func readAllPages(
+	client *dynamodb.Client,
+	p readParams,
+	startKey map[string]types.AttributeValue,
+) ([]map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+
+	for {
+		page, lastKey, err := readPage(client, p, startKey)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, page...)
+
+		if len(lastKey) == 0 {
+			return items, nil
+		}
+		startKey = lastKey
+	}
+}
+
+func buildScanInput(
+	tableName string,
+	filters []filter.FilterCondition,
+	startKey map[string]types.AttributeValue,
+) (*dynamodb.ScanInput, error) {
+	input := &dynamodb.ScanInput{
+		TableName:         aws.String(tableName),
+		ExclusiveStartKey: startKey,
+	}
+
+	// Build FilterExpression from conditions
+	if len(filters) > 0 {
+		filterExpr, exprAttrNames, exprAttrValues, err := buildFilterExpression(filters)
+		if err != nil {
+			return nil, err
+		}
+		if filterExpr != "" {
 			input.FilterExpression = aws.String(filterExpr)
 			input.ExpressionAttributeNames = exprAttrNames
 			input.ExpressionAttributeValues = exprAttrValues
 		}
+	}
+
+	return input, nil
+}
 
-		result, err := m.client.Scan(context.TODO(), input)
+func buildQueryInput(
+	p readParams,
+	startKey map[string]types.AttributeValue,
+) (*dynamodb.QueryInput, error) {
+	keyExpr, exprAttrNames, exprAttrValues, err := buildKeyConditionExpression(p.Keys, *p.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(p.TableName),
+		KeyConditionExpression: aws.String(keyExpr),
+		ScanIndexForward:       aws.Bool(p.Query.ScanForward),
+		ExclusiveStartKey:      startKey,
+	}
+	if p.IndexName != "" {
+		input.IndexName = aws.String(p.IndexName)
+	}
+
+	// Item filters still apply on top of the key condition
+	if len(p.Filters) > 0 {
+		filterExpr, filterNames, filterValues, err := buildFilterExpression(p.Filters)
 		if err != nil {
-			return itemsLoadedMsg{err: err}
+			return nil, err
+		}
+		if filterExpr != "" {
+			input.FilterExpression = aws.String(filterExpr)
 		}
+		for k, v := range filterNames {
+			exprAttrNames[k] = v
+		}
+		for k, v := range filterValues {
+			exprAttrValues[k] = v
+		}
+	}
 
-		return itemsLoadedMsg{
-			items: result.Items,
+	input.ExpressionAttributeNames = exprAttrNames
+	input.ExpressionAttributeValues = exprAttrValues
+
+	return input, nil
+}
+
+func buildKeyConditionExpression(
+	keys TableKeySchema,
+	q KeyQuery,
+) (string, map[string]string, map[string]types.AttributeValue, error) {
+	exprAttrNames := map[string]string{"#pk": keys.PartitionKey}
+	exprAttrValues := make(map[string]types.AttributeValue)
+
+	pkValue, err := keyAttributeValue(keys.PartitionKeyType, q.PartitionValue)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%s: %w", keys.PartitionKey, err)
+	}
+	exprAttrValues[":pk"] = pkValue
+	expr := "#pk = :pk"
+
+	if q.SortOperator == "" {
+		return expr, exprAttrNames, exprAttrValues, nil
+	}
+	if keys.SortKey == "" {
+		return "", nil, nil, fmt.Errorf("table has no sort key")
+	}
+
+	exprAttrNames["#sk"] = keys.SortKey
+	skValue, err := keyAttributeValue(keys.SortKeyType, q.SortValue)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("%s: %w", keys.SortKey, err)
+	}
+	exprAttrValues[":sk"] = skValue
+
+	switch q.SortOperator {
+	case "=", "<", "<=", ">", ">=":
+		expr += fmt.Sprintf(" AND #sk %s :sk", q.SortOperator)
+	case "begins_with":
+		expr += " AND begins_with(#sk, :sk)"
+	case "between":
+		skValue2, err := keyAttributeValue(keys.SortKeyType, q.SortValue2)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("%s: %w", keys.SortKey, err)
 		}
+		exprAttrValues[":sk2"] = skValue2
+		expr += " AND #sk BETWEEN :sk AND :sk2"
+	default:
+		return "", nil, nil, fmt.Errorf("unknown sort key operator %q", q.SortOperator)
 	}
+
+	return expr, exprAttrNames, exprAttrValues, nil
 }
 
 func buildFilterExpression(
 	filters []filter.FilterCondition,
-) (string, map[string]string, map[string]types.AttributeValue) {
+) (string, map[string]string, map[string]types.AttributeValue, error) {
 	var expressions []string
-	exprAttrNames := make(map[string]string)
-	exprAttrValues := make(map[string]types.AttributeValue)
-
-	for i, filter := range filters {
-		nameKey := fmt.Sprintf("#attr%d", i)
-		valueKey := fmt.Sprintf(":val%d", i)
+	b := &filterExpressionBuilder{
+		names:  make(map[string]string),
+		values: make(map[string]types.AttributeValue),
+	}
 
-		exprAttrNames[nameKey] = filter.Column
-		exprAttrValues[valueKey] = &types.AttributeValueMemberS{Value: filter.Value}
+	for _, cond := range filters {
+		if err := cond.Validate(); err != nil {
+			return "", nil, nil, fmt.Errorf("filter %s: %w", cond.String(), err)
+		}
 
-		var expr string
-		switch filter.Operator {
-		case "==":
-			expr = fmt.Sprintf("%s = %s", nameKey, valueKey)
-		case "!=":
-			expr = fmt.Sprintf("%s <> %s", nameKey, valueKey)
-		case "contains":
-			expr = fmt.Sprintf("contains(%s, %s)", nameKey, valueKey)
-		case "startswith":
-			expr = fmt.Sprintf("begins_with(%s, %s)", nameKey, valueKey)
-		case "endswith":
-			// DynamoDB doesn't have endswith, need to use contains as fallback
-			expr = fmt.Sprintf("contains(%s, %s)", nameKey, valueKey)
+		// Client-side conditions are applied after the page is loaded
+		if !cond.IsGroup() && filter.IsClientSide(cond.Operator) {
+			continue
 		}
 
+		expr, err := b.build(cond)
+		if err != nil {
+			return "", nil, nil, err
+		}
 		expressions = append(expressions, expr)
 	}
 
+	exprAttrNames, exprAttrValues := b.names, b.values
+
+	// DynamoDB rejects empty expression maps
+	if len(exprAttrNames) == 0 {
+		exprAttrNames = nil
+	}
+	if len(exprAttrValues) == 0 {
+		exprAttrValues = nil
+	}
+
 	filterExpr := strings.Join(expressions, " AND ")
-	return filterExpr, exprAttrNames, exprAttrValues
+	return filterExpr, exprAttrNames, exprAttrValues, nil
+}
+
+// filterExpressionBuilder numbers placeholders across a whole condition tree
+type filterExpressionBuilder struct {
+	names  map[string]string
+	values map[string]types.AttributeValue
+	next   int
+}
+
+func (b *filterExpressionBuilder) build(cond filter.FilterCondition) (string, error) {
+	var expr string
+
+	if cond.IsGroup() {
+		var parts []string
+		for _, child := range cond.Children {
+			part, err := b.build(child)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		expr = "(" + strings.Join(parts, " "+strings.ToUpper(cond.Group)+" ") + ")"
+	} else {
+		leaf, err := b.buildLeaf(cond)
+		if err != nil {
+			return "", err
+		}
+		expr = leaf
+	}
+
+	if cond.Negate {
+		return fmt.Sprintf("NOT (%s)", expr), nil
+	}
+	return expr, nil
+}
+
+func (b *filterExpressionBuilder) buildLeaf(cond filter.FilterCondition) (string, error) {
+	i := b.next
+	b.next++
+
+	nameKey, err := pathExpression(cond.Column, fmt.Sprintf("#attr%d", i), b.names)
+	if err != nil {
+		return "", fmt.Errorf("filter on %s: %w", cond.Column, err)
+	}
+
+	var valueKeys []string
+	for j, operand := range cond.Operands() {
+		valueKey := fmt.Sprintf(":val%d", i)
+		if len(cond.Operands()) > 1 {
+			valueKey = fmt.Sprintf(":val%d_%d", i, j)
+		}
+
+		value, err := filterAttributeValue(cond, operand)
+		if err != nil {
+			return "", fmt.Errorf("filter on %s: %w", cond.Column, err)
+		}
+		b.values[valueKey] = value
+		valueKeys = append(valueKeys, valueKey)
+	}
+
+	switch cond.Operator {
+	case "==":
+		return fmt.Sprintf("%s = %s", nameKey, valueKeys[0]), nil
+	case "!=":
+		return fmt.Sprintf("%s <> %s", nameKey, valueKeys[0]), nil
+	case "<", "<=", ">", ">=":
+		return fmt.Sprintf("%s %s %s", nameKey, cond.Operator, valueKeys[0]), nil
+	case "between":
+		return fmt.Sprintf("%s BETWEEN %s AND %s", nameKey, valueKeys[0], valueKeys[1]), nil
+	case "in":
+		return fmt.Sprintf("%s IN (%s)", nameKey, strings.Join(valueKeys, ", ")), nil
+	case "contains":
+		return fmt.Sprintf("contains(%s, %s)", nameKey, valueKeys[0]), nil
+	case "startswith":
+		return fmt.Sprintf("begins_with(%s, %s)", nameKey, valueKeys[0]), nil
+	case "exists":
+		return fmt.Sprintf("attribute_exists(%s)", nameKey), nil
+	case "notexists":
+		return fmt.Sprintf("attribute_not_exists(%s)", nameKey), nil
+	case "type":
+		return fmt.Sprintf("attribute_type(%s, %s)", nameKey, valueKeys[0]), nil
+	}
+
+	// size==, size<, ... compare the attribute's size
+	comparator := filter.SizeComparator(cond.Operator)
+	switch comparator {
+	case "==":
+		comparator = "="
+	case "!=":
+		comparator = "<>"
+	}
+	return fmt.Sprintf("size(%s) %s %s", nameKey, comparator, valueKeys[0]), nil
+}
+
+// filterAttributeValue converts one operand of a condition into an AttributeValue of its type
+func filterAttributeValue(c filter.FilterCondition, value string) (types.AttributeValue, error) {
+	switch {
+	case c.Operator == "type":
+		return &types.AttributeValueMemberS{Value: strings.ToUpper(value)}, nil
+	case filter.IsSizeOperator(c.Operator):
+		return &types.AttributeValueMemberN{Value: value}, nil
+	}
+
+	switch c.Type() {
+	case filter.ValueTypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return &types.AttributeValueMemberN{Value: value}, nil
+
+	case filter.ValueTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return &types.AttributeValueMemberBOOL{Value: b}, nil
+
+	case filter.ValueTypeNull:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+
+	default:
+		return &types.AttributeValueMemberS{Value: value}, nil
+	}
+}
+
+// applyClientFilters drops items that fail conditions DynamoDB can't evaluate
+func applyClientFilters(
+	items []map[string]types.AttributeValue,
+	filters []filter.FilterCondition,
+) []map[string]types.AttributeValue {
+	var clientFilters []filter.FilterCondition
+	for _, f := range filters {
+		if !f.IsGroup() && filter.IsClientSide(f.Operator) {
+			clientFilters = append(clientFilters, f)
+		}
+	}
+	if len(clientFilters) == 0 {
+		return items
+	}
+
+	var matched []map[string]types.AttributeValue
+	for _, item := range items {
+		keep := true
+		for _, f := range clientFilters {
+			if matchesClientFilter(item, f) == f.Negate {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+func matchesClientFilter(item map[string]types.AttributeValue, f filter.FilterCondition) bool {
+	av, ok := resolveAttributePath(item, f.Column)
+	if !ok {
+		return false
+	}
+
+	switch f.Operator {
+	case "endswith":
+		switch v := av.(type) {
+		case *types.AttributeValueMemberS:
+			return strings.HasSuffix(v.Value, f.Value)
+		case *types.AttributeValueMemberN:
+			return strings.HasSuffix(v.Value, f.Value)
+		}
+	}
+	return false
+}
+
+// resolveFilterTypes replaces inferred value types with the type seen in sampled items
+func resolveFilterTypes(
+	filters []filter.FilterCondition,
+	columnTypes map[string]string,
+	items []map[string]types.AttributeValue,
+) []filter.FilterCondition {
+	resolved := make([]filter.FilterCondition, len(filters))
+	for i, f := range filters {
+		if f.IsGroup() {
+			f.Children = resolveFilterTypes(f.Children, columnTypes, items)
+		}
+		if f.ValueType == filter.ValueTypeAuto {
+			f.ValueType = columnTypes[f.Column]
+			if f.ValueType == "" && isNestedPath(f.Column) {
+				f.ValueType = samplePathType(items, f.Column)
+			}
+			if f.ValueType == "" || (f.Operator == "startswith" && f.ValueType != filter.ValueTypeString) {
+				f.ValueType = filter.ValueTypeString
+			}
+		}
+		resolved[i] = f
+	}
+	return resolved
+}
+
+// listAllEnvTables lists the tables of every environment
+func listAllEnvTables(client *dynamodb.Client, cfg *config.Config) ([]string, error) {
+	matchers, err := cfg.GetAllEnvTableMatchers()
+	if err != nil {
+		return nil, err
+	}
+	return discoverTables(client, matchers)
+}
+
+// Concurrent DescribeTable/ListTagsOfResource calls when discovery filters by tag
+const tagLookupWorkers = 8
+
+// discoverTables pages through ListTables and keeps the tables any matcher
+// accepts. Tags are only looked up for tables whose name already matched.
+func discoverTables(client *dynamodb.Client, matchers []*config.NameMatcher) ([]string, error) {
+	var candidates []string
+	tagged := make(map[string][]*config.NameMatcher) // Tables that still need a tag check
+
+	paginator := dynamodb.NewListTablesPaginator(client, &dynamodb.ListTablesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+
+	tables:
+		for _, table := range page.TableNames {
+			var needTags []*config.NameMatcher
+			for _, matcher := range matchers {
+				if !matcher.Match(table) {
+					continue
+				}
+				if len(matcher.Tags) == 0 {
+					candidates = append(candidates, table)
+					continue tables
+				}
+				needTags = append(needTags, matcher)
+			}
+			if len(needTags) > 0 {
+				tagged[table] = needTags
+			}
+		}
+	}
+
+	if len(tagged) > 0 {
+		matched, err := matchTableTags(client, tagged)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, matched...)
+	}
+
+	sort.Strings(candidates)
+	return candidates, nil
+}
+
+// matchTableTags looks up the tags of each table and keeps those a matcher accepts
+func matchTableTags(client *dynamodb.Client, tables map[string][]*config.NameMatcher) ([]string, error) {
+	names := make(chan string)
+	var mu sync.Mutex
+	var matched []string
+	var firstErr error
+
+	var wg sync.WaitGroup
+	for range min(tagLookupWorkers, len(tables)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for table := range names {
+				tags, err := tableTags(client, table)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("failed to read tags of %s: %w", table, err)
+				}
+				for _, matcher := range tables[table] {
+					if err == nil && matcher.MatchTags(tags) {
+						matched = append(matched, table)
+						break
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for table := range tables {
+		names <- table
+	}
+	close(names)
+	wg.Wait()
+
+	return matched, firstErr
+}
+
+// tableTags reads every tag of a table
+func tableTags(client *dynamodb.Client, tableName string) (map[string]string, error) {
+	described, err := client.DescribeTable(context.TODO(), &dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string)
+	var nextToken *string
+	for {
+		result, err := client.ListTagsOfResource(context.TODO(), &dynamodb.ListTagsOfResourceInput{
+			ResourceArn: described.Table.TableArn,
+			NextToken:   nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range result.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+		if nextToken = result.NextToken; nextToken == nil {
+			return tags, nil
+		}
+	}
 }
@@ -0,0 +1,205 @@
+package dynamo
+
+import (
+	"cirrus/internal/services/dynamo/filter"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func cond(column, operator, value, valueType string) filter.FilterCondition {
+	return filter.FilterCondition{Column: column, Operator: operator, Value: value, ValueType: valueType}
+}
+
+func condValues(column, operator, valueType string, values ...string) filter.FilterCondition {
+	return filter.FilterCondition{Column: column, Operator: operator, Values: values, ValueType: valueType}
+}
+
+func condGroup(name string, children ...filter.FilterCondition) filter.FilterCondition {
+	return filter.FilterCondition{Group: name, Children: children}
+}
+
+func condNot(c filter.FilterCondition) filter.FilterCondition {
+	c.Negate = true
+	return c
+}
+
+func avS(v string) types.AttributeValue { return &types.AttributeValueMemberS{Value: v} }
+func avN(v string) types.AttributeValue { return &types.AttributeValueMemberN{Value: v} }
+
+func TestBuildFilterExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		filters    []filter.FilterCondition
+		wantExpr   string
+		wantNames  map[string]string
+		wantValues map[string]types.AttributeValue
+	}{
+		{
+			name: "no filters",
+		},
+		{
+			name: "typed values",
+			filters: []filter.FilterCondition{
+				cond("age", ">=", "30", filter.ValueTypeNumber),
+				cond("active", "==", "true", filter.ValueTypeBool),
+				cond("deletedAt", "==", "", filter.ValueTypeNull),
+				cond("name", "!=", "30", filter.ValueTypeString),
+				cond("legacy", "<", "b", ""),
+			},
+			wantExpr: "#attr0 >= :val0 AND #attr1 = :val1 AND #attr2 = :val2 AND #attr3 <> :val3 AND #attr4 < :val4",
+			wantNames: map[string]string{
+				"#attr0": "age", "#attr1": "active", "#attr2": "deletedAt", "#attr3": "name", "#attr4": "legacy",
+			},
+			wantValues: map[string]types.AttributeValue{
+				":val0": avN("30"),
+				":val1": &types.AttributeValueMemberBOOL{Value: true},
+				":val2": &types.AttributeValueMemberNULL{Value: true},
+				":val3": avS("30"),
+				":val4": avS("b"),
+			},
+		},
+		{
+			name: "placeholders are numbered across groups",
+			filters: []filter.FilterCondition{
+				cond("a", "==", "x", filter.ValueTypeString),
+				condGroup(filter.GroupOr,
+					cond("b", "==", "1", filter.ValueTypeNumber),
+					condGroup(filter.GroupAnd,
+						cond("c", "startswith", "y", filter.ValueTypeString),
+						cond("d", "exists", "", ""),
+					),
+				),
+				cond("e", "contains", "z", filter.ValueTypeString),
+			},
+			wantExpr: "#attr0 = :val0 AND (#attr1 = :val1 OR (begins_with(#attr2, :val2) AND attribute_exists(#attr3))) AND contains(#attr4, :val4)",
+			wantNames: map[string]string{
+				"#attr0": "a", "#attr1": "b", "#attr2": "c", "#attr3": "d", "#attr4": "e",
+			},
+			wantValues: map[string]types.AttributeValue{
+				":val0": avS("x"),
+				":val1": avN("1"),
+				":val2": avS("y"),
+				":val4": avS("z"),
+			},
+		},
+		{
+			name: "not",
+			filters: []filter.FilterCondition{
+				condNot(cond("status", "==", "done", filter.ValueTypeString)),
+				condNot(condGroup(filter.GroupOr,
+					cond("a", "exists", "", ""),
+					cond("b", "notexists", "", ""),
+				)),
+			},
+			wantExpr:  "NOT (#attr0 = :val0) AND NOT ((attribute_exists(#attr1) OR attribute_not_exists(#attr2)))",
+			wantNames: map[string]string{"#attr0": "status", "#attr1": "a", "#attr2": "b"},
+			wantValues: map[string]types.AttributeValue{
+				":val0": avS("done"),
+			},
+		},
+		{
+			name: "size and type",
+			filters: []filter.FilterCondition{
+				cond("tags", "size>=", "2", filter.ValueTypeString),
+				cond("tags", "size==", "3", ""),
+				cond("name", "size!=", "0", ""),
+				cond("payload", "type", "m", ""),
+			},
+			wantExpr:  "size(#attr0) >= :val0 AND size(#attr1) = :val1 AND size(#attr2) <> :val2 AND attribute_type(#attr3, :val3)",
+			wantNames: map[string]string{"#attr0": "tags", "#attr1": "tags", "#attr2": "name", "#attr3": "payload"},
+			wantValues: map[string]types.AttributeValue{
+				":val0": avN("2"),
+				":val1": avN("3"),
+				":val2": avN("0"),
+				":val3": avS("M"),
+			},
+		},
+		{
+			name: "in and between",
+			filters: []filter.FilterCondition{
+				condValues("status", "in", filter.ValueTypeString, "new", "open", "done"),
+				condValues("age", "between", filter.ValueTypeNumber, "18", "65"),
+				condValues("code", "in", filter.ValueTypeNumber, "7"),
+			},
+			wantExpr:  "#attr0 IN (:val0_0, :val0_1, :val0_2) AND #attr1 BETWEEN :val1_0 AND :val1_1 AND #attr2 IN (:val2)",
+			wantNames: map[string]string{"#attr0": "status", "#attr1": "age", "#attr2": "code"},
+			wantValues: map[string]types.AttributeValue{
+				":val0_0": avS("new"),
+				":val0_1": avS("open"),
+				":val0_2": avS("done"),
+				":val1_0": avN("18"),
+				":val1_1": avN("65"),
+				":val2":   avN("7"),
+			},
+		},
+		{
+			name: "nested paths",
+			filters: []filter.FilterCondition{
+				cond("a.b[0].c", "==", "x", filter.ValueTypeString),
+				condGroup(filter.GroupOr,
+					cond("events[1][2]", "exists", "", ""),
+					cond("`odd.name`.v", ">", "1", filter.ValueTypeNumber),
+				),
+			},
+			wantExpr: "#attr0_0.#attr0_1[0].#attr0_2 = :val0 AND (attribute_exists(#attr1_0[1][2]) OR #attr2_0.#attr2_1 > :val2)",
+			wantNames: map[string]string{
+				"#attr0_0": "a", "#attr0_1": "b", "#attr0_2": "c",
+				"#attr1_0": "events",
+				"#attr2_0": "odd.name", "#attr2_1": "v",
+			},
+			wantValues: map[string]types.AttributeValue{
+				":val0": avS("x"),
+				":val2": avN("1"),
+			},
+		},
+		{
+			name: "client-side conditions are left out",
+			filters: []filter.FilterCondition{
+				cond("name", "endswith", "x", filter.ValueTypeString),
+				cond("a", "exists", "", ""),
+			},
+			wantExpr:  "attribute_exists(#attr0)",
+			wantNames: map[string]string{"#attr0": "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, names, values, err := buildFilterExpression(tt.filters)
+			if err != nil {
+				t.Fatalf("buildFilterExpression failed: %v", err)
+			}
+			if expr != tt.wantExpr {
+				t.Errorf("expression:\n got %s\nwant %s", expr, tt.wantExpr)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("names = %v, want %v", names, tt.wantNames)
+			}
+			if !reflect.DeepEqual(values, tt.wantValues) {
+				t.Errorf("values = %#v, want %#v", values, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestBuildFilterExpressionErrors(t *testing.T) {
+	tests := map[string]filter.FilterCondition{
+		"not a number":      cond("age", "==", "old", filter.ValueTypeNumber),
+		"not a boolean":     cond("active", "==", "maybe", filter.ValueTypeBool),
+		"invalid path":      cond("a..b", "==", "x", filter.ValueTypeString),
+		"unknown operator":  cond("a", "~", "x", filter.ValueTypeString),
+		"between one value": condValues("a", "between", filter.ValueTypeNumber, "1"),
+		"client-side in a group": condGroup(filter.GroupOr,
+			cond("a", "endswith", "x", filter.ValueTypeString),
+			cond("b", "exists", "", ""),
+		),
+	}
+
+	for name, c := range tests {
+		if _, _, _, err := buildFilterExpression([]filter.FilterCondition{c}); err == nil {
+			t.Errorf("%s: buildFilterExpression succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,455 @@
+package dynamo
+
+import (
+	"cirrus/internal/messages"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DynamoDB BatchGetItem supports up to 100 keys per request
+const batchGetSize = 100
+
+// Retries for throttled target lookups made while copying
+const maxReadRetries = 8
+
+// Conflicting keys listed in the copy report; the rest are only counted
+const maxCopyConflictSamples = 20
+
+type copyTargetsLoadedMsg struct {
+	tables []string
+	err    error
+}
+
+type copyTargetKeysLoadedMsg struct {
+	tableName string
+	keys      TableKeySchema
+	err       error
+}
+
+// copyPlan says where copied items go and how their keys change on the way
+type copyPlan struct {
+	target     string
+	sourceKeys TableKeySchema
+	targetKeys TableKeySchema
+	fromPrefix string // Empty leaves key values alone
+	toPrefix   string
+	overwrite  bool // Replace items that already exist in the target instead of skipping them
+}
+
+// copyReport collects what a copy skipped or replaced. It is only written by
+// the job's reader, and only read by the UI once the job is done.
+type copyReport struct {
+	written     int
+	conflicts   int
+	samples     []string // Target keys of the first conflicts
+	overwritten int
+	backup      *backupWriter // Target items replaced by an overwriting copy
+	backupPath  string
+	backupErr   error
+}
+
+// checkKeysCompatible reports whether items keyed for one table can be written to another.
+// Attribute names may differ, since copies rename them; types and the presence of a sort key may not.
+func checkKeysCompatible(source, target TableKeySchema) error {
+	if source.PartitionKeyType != target.PartitionKeyType {
+		return fmt.Errorf("partition keys differ: %s (%s) vs %s (%s)",
+			source.PartitionKey, source.PartitionKeyType, target.PartitionKey, target.PartitionKeyType)
+	}
+	if (source.SortKey == "") != (target.SortKey == "") {
+		return fmt.Errorf("only one of the tables has a sort key")
+	}
+	if source.SortKeyType != target.SortKeyType {
+		return fmt.Errorf("sort keys differ: %s (%s) vs %s (%s)",
+			source.SortKey, source.SortKeyType, target.SortKey, target.SortKeyType)
+	}
+	return nil
+}
+
+// transform returns the item as it should be written to the target table.
+// The target keys are built from the original item before any source key is
+// removed, so swapped key names don't overwrite each other.
+func (p copyPlan) transform(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	renames := [][2]string{{p.sourceKeys.PartitionKey, p.targetKeys.PartitionKey}}
+	if p.sourceKeys.SortKey != "" {
+		renames = append(renames, [2]string{p.sourceKeys.SortKey, p.targetKeys.SortKey})
+	}
+
+	keys := make(map[string]types.AttributeValue, len(renames))
+	for _, r := range renames {
+		av, ok := item[r[0]]
+		if !ok {
+			continue
+		}
+		if s, ok := av.(*types.AttributeValueMemberS); ok && p.fromPrefix != "" && strings.HasPrefix(s.Value, p.fromPrefix) {
+			av = &types.AttributeValueMemberS{Value: p.toPrefix + strings.TrimPrefix(s.Value, p.fromPrefix)}
+		}
+		keys[r[1]] = av
+	}
+
+	out := make(map[string]types.AttributeValue, len(item))
+	for name, av := range item {
+		out[name] = av
+	}
+	for _, r := range renames {
+		delete(out, r[0])
+	}
+	for name, av := range keys {
+		out[name] = av
+	}
+	return out
+}
+
+// slicePager returns already loaded items as a single page
+func slicePager(items []map[string]types.AttributeValue) itemPager {
+	done := false
+	return func(ctx context.Context) ([]map[string]types.AttributeValue, bool, error) {
+		if done {
+			return nil, false, nil
+		}
+		done = true
+		return items, false, nil
+	}
+}
+
+// copyPager reads pages from the source, rewrites them for the target and looks
+// the rewritten keys up in the target. Items that already exist there are left
+// out and reported, or backed up and kept when the plan overwrites.
+func copyPager(client *dynamodb.Client, source itemPager, plan copyPlan, report *copyReport) itemPager {
+	return func(ctx context.Context) ([]map[string]types.AttributeValue, bool, error) {
+		page, more, err := source(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+
+		items := make([]map[string]types.AttributeValue, 0, len(page))
+		keys := make([]map[string]types.AttributeValue, 0, len(page))
+		seen := make(map[string]bool, len(page))
+		for _, item := range page {
+			item = plan.transform(item)
+			key := keyString(item, plan.targetKeys)
+			if seen[key] {
+				// A batch can't put the same key twice, e.g. after a prefix rewrite
+				report.conflict(key)
+				continue
+			}
+			seen[key] = true
+			items = append(items, item)
+			keys = append(keys, itemKey(item, plan.targetKeys))
+		}
+
+		existing, err := getExistingItems(ctx, client, plan.target, plan.targetKeys, keys)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to look up target items: %w", err)
+		}
+		if len(existing) == 0 {
+			return items, more, nil
+		}
+
+		kept := items[:0]
+		var replaced []map[string]types.AttributeValue
+		for _, item := range items {
+			key := keyString(item, plan.targetKeys)
+			if old, ok := existing[key]; ok {
+				if !plan.overwrite {
+					report.conflict(key)
+					continue
+				}
+				replaced = append(replaced, old)
+			}
+			kept = append(kept, item)
+		}
+
+		// Replaced target items are safely in the backup before they are written over
+		if len(replaced) > 0 {
+			if err := report.backup.write(replaced); err != nil {
+				return nil, false, fmt.Errorf("failed to write backup: %w", err)
+			}
+			report.overwritten += len(replaced)
+		}
+		return kept, more, nil
+	}
+}
+
+func (r *copyReport) conflict(key string) {
+	r.conflicts++
+	if len(r.samples) < maxCopyConflictSamples {
+		r.samples = append(r.samples, key)
+	}
+}
+
+// getExistingItems reads the given keys from a table with BatchGetItem and
+// returns the items found, by key string
+func getExistingItems(
+	ctx context.Context,
+	client *dynamodb.Client,
+	tableName string,
+	keySchema TableKeySchema,
+	keys []map[string]types.AttributeValue,
+) (map[string]map[string]types.AttributeValue, error) {
+	found := make(map[string]map[string]types.AttributeValue)
+
+	for start := 0; start < len(keys); start += batchGetSize {
+		pending := keys[start:min(start+batchGetSize, len(keys))]
+
+		for attempt := 0; len(pending) > 0; attempt++ {
+			result, err := client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: map[string]types.KeysAndAttributes{
+					tableName: {Keys: pending, ConsistentRead: aws.Bool(true)},
+				},
+			})
+			if err != nil && (!isRetryableWriteError(err) || attempt >= maxReadRetries) {
+				return nil, err
+			}
+			if err == nil {
+				for _, item := range result.Responses[tableName] {
+					found[keyString(item, keySchema)] = item
+				}
+				pending = result.UnprocessedKeys[tableName].Keys
+				if len(pending) > 0 && attempt >= maxReadRetries {
+					return nil, fmt.Errorf("%d keys still unprocessed after %d retries", len(pending), attempt)
+				}
+			}
+			if len(pending) > 0 {
+				if err := sleepContext(ctx, backoff(attempt)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// startCopy picks the items to copy, the marked rows or else the whole
+// result set, and lists the tables they can be copied to
+func (m Model) startCopy() (tea.Model, tea.Cmd) {
+	if cmd := m.refusePartiQLRows("copy"); cmd != nil {
+		return m, cmd
+	}
+	for _, idx := range m.tableIndexes[m.selectedTable] {
+		if idx.Name == m.selectedIndex && idx.Projection != string(types.ProjectionTypeAll) {
+			return m, messages.ShowToast("Items read from this index are partial; switch to the base table to copy", messages.ToastWarning)
+		}
+	}
+
+	m.copyItems = m.markedRows()
+	m.state = stateLoading
+	return m, m.loadCopyTargets()
+}
+
+// loadCopyTargets lists the tables of every environment
+func (m Model) loadCopyTargets() tea.Cmd {
+	return func() tea.Msg {
+		tables, err := listAllEnvTables(m.client, m.config)
+		return copyTargetsLoadedMsg{tables: tables, err: err}
+	}
+}
+
+func (m Model) handleCopyTargetsLoaded(msg copyTargetsLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.state = stateItemList
+		return m, nil
+	}
+
+	m.copyTargets = msg.tables
+	m.copyCursor = 0
+	for i, table := range msg.tables {
+		if table == m.selectedTable {
+			m.copyCursor = i
+		}
+	}
+	m.state = stateCopyTargetPicker
+	return m, nil
+}
+
+func (m Model) updateCopyTargetPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.copyItems = nil
+		m.state = stateItemList
+
+	case "up", "k":
+		if m.copyCursor > 0 {
+			m.copyCursor--
+		}
+
+	case "down", "j":
+		if m.copyCursor < len(m.copyTargets)-1 {
+			m.copyCursor++
+		}
+
+	case "enter":
+		if len(m.copyTargets) == 0 {
+			return m, nil
+		}
+		m.state = stateLoading
+		return m, m.loadCopyTargetKeys(m.copyTargets[m.copyCursor])
+	}
+
+	return m, nil
+}
+
+func (m Model) loadCopyTargetKeys(tableName string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := m.client.DescribeTable(context.TODO(), &dynamodb.DescribeTableInput{
+			TableName: aws.String(tableName),
+		})
+		if err != nil {
+			return copyTargetKeysLoadedMsg{tableName: tableName, err: err}
+		}
+		return copyTargetKeysLoadedMsg{
+			tableName: tableName,
+			keys:      keySchemaFromDescription(result.Table.KeySchema, result.Table.AttributeDefinitions),
+		}
+	}
+}
+
+func (m Model) handleCopyTargetKeysLoaded(msg copyTargetKeysLoadedMsg) (tea.Model, tea.Cmd) {
+	m.state = stateCopyTargetPicker
+	if msg.err != nil {
+		return m, messages.ShowToast(fmt.Sprintf("Failed to describe %s: %v", msg.tableName, msg.err), messages.ToastError)
+	}
+	if err := checkKeysCompatible(m.tableKeys[m.selectedTable], msg.keys); err != nil {
+		return m, messages.ShowToast(fmt.Sprintf("Can't copy to %s: %v", msg.tableName, err), messages.ToastError)
+	}
+
+	m.copyPlan = copyPlan{
+		target:     msg.tableName,
+		sourceKeys: m.tableKeys[m.selectedTable],
+		targetKeys: msg.keys,
+	}
+	m.copyFromInput = newCopyPrefixInput("Prefix to replace, e.g. dev#")
+	m.copyToInput = newCopyPrefixInput("Replacement, e.g. staging#")
+	m.copyToInput.Blur()
+	m.copyErr = nil
+	m.state = stateCopyOptions
+	return m, textinput.Blink
+}
+
+func newCopyPrefixInput(placeholder string) textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = placeholder
+	ti.Focus()
+	ti.Width = 40
+	return ti
+}
+
+func (m Model) updateCopyOptions(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.state = stateCopyTargetPicker
+		return m, nil
+
+	case "tab", "shift+tab", "up", "down":
+		if m.copyFromInput.Focused() {
+			m.copyFromInput.Blur()
+			m.copyToInput.Focus()
+		} else {
+			m.copyToInput.Blur()
+			m.copyFromInput.Focus()
+		}
+		return m, nil
+
+	case "ctrl+o":
+		m.copyPlan.overwrite = !m.copyPlan.overwrite
+		return m, nil
+
+	case "enter":
+		m.copyPlan.fromPrefix = m.copyFromInput.Value()
+		m.copyPlan.toPrefix = m.copyToInput.Value()
+		if m.copyPlan.fromPrefix != "" &&
+			m.copyPlan.targetKeys.PartitionKeyType != types.ScalarAttributeTypeS &&
+			m.copyPlan.targetKeys.SortKeyType != types.ScalarAttributeTypeS {
+			m.copyErr = fmt.Errorf("prefixes can only be rewritten on string keys")
+			return m, nil
+		}
+		return m.startCopyJob()
+	}
+
+	var cmd tea.Cmd
+	if m.copyFromInput.Focused() {
+		m.copyFromInput, cmd = m.copyFromInput.Update(msg)
+	} else {
+		m.copyToInput, cmd = m.copyToInput.Update(msg)
+	}
+	m.copyErr = nil
+	return m, cmd
+}
+
+// startCopyJob copies the marked rows, or every page of the result set, into the target table
+func (m Model) startCopyJob() (tea.Model, tea.Cmd) {
+	report := &copyReport{}
+	if m.copyPlan.overwrite {
+		backup, err := m.newTableBackupWriter(m.copyPlan.target, "copy-overwrite")
+		if err != nil {
+			m.copyErr = fmt.Errorf("failed to create backup, nothing was copied: %w", err)
+			return m, nil
+		}
+		report.backup = backup
+	}
+
+	source, total := slicePager(m.copyItems), len(m.copyItems)
+	if len(m.copyItems) == 0 {
+		source, total = m.resultPager(), 0
+		if !m.hasMorePages() {
+			total = len(m.items)
+		}
+	}
+
+	m.copyReport = report
+	return m.startBatchJob(batchJob{
+		kind:      jobCopy,
+		tableName: m.copyPlan.target,
+		total:     total,
+		pager:     copyPager(m.client, source, m.copyPlan, report),
+		requests:  putRequests,
+	})
+}
+
+// finishCopy closes the copy's backup and shows its conflicts, if there were any
+func (m Model) finishCopy() (Model, tea.Cmd) {
+	report := m.copyReport
+	m.copyItems = nil
+
+	if report.backup != nil {
+		if path, err := report.backup.close(); err != nil {
+			report.backupErr = fmt.Errorf("failed to close backup: %w", err)
+		} else {
+			report.backupPath = path
+		}
+	}
+
+	if report.conflicts > 0 {
+		m.state = stateCopyReport
+		return m, nil
+	}
+
+	m.copyReport = nil
+	m.state = stateItemList
+	if m.copyPlan.target == m.selectedTable {
+		m.state = stateLoading
+		return m, m.loadItems(m.activeFilters)
+	}
+	return m, nil
+}
+
+func (m Model) updateCopyReport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "enter", "q":
+		m.copyReport = nil
+		m.state = stateItemList
+		if m.copyPlan.target == m.selectedTable {
+			m.state = stateLoading
+			return m, m.loadItems(m.activeFilters)
+		}
+	}
+	return m, nil
+}
@@ -1,6 +1,7 @@
 package dynamo
 
 import (
+	"cirrus/internal/messages"
 	"context"
 	"fmt"
 
@@ -10,9 +11,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-type deleteCompleteMsg struct {
-	deleted int
-	err     error
+type matchingKeysLoadedMsg struct {
+	keys []map[string]types.AttributeValue
+	err  error
 }
 
 func newDeleteConfirmInput() textinput.Model {
@@ -23,59 +24,154 @@ func newDeleteConfirmInput() textinput.Model {
 	return ti
 }
 
-func (m Model) deleteAllItems() tea.Cmd {
+// readPager pages through a scan or query of the item list's table
+func (m Model) readPager(params readParams) itemPager {
+	client := m.client
+	var startKey map[string]types.AttributeValue
+
+	return func(ctx context.Context) ([]map[string]types.AttributeValue, bool, error) {
+		items, lastKey, err := readPage(client, params, startKey)
+		if err != nil {
+			return nil, false, err
+		}
+		startKey = lastKey
+		return items, len(lastKey) > 0, nil
+	}
+}
+
+// loadMatchingKeys reads every page of the current query or scan with the
+// active filters and keeps only the primary keys of the matches
+func (m Model) loadMatchingKeys() tea.Cmd {
+	params := m.readParams(m.activeFilters)
+	keySchema := m.tableKeys[m.selectedTable]
+
 	return func() tea.Msg {
-		keySchema := m.tableKeys[m.selectedTable]
-		deleted := 0
+		var keys []map[string]types.AttributeValue
+		var startKey map[string]types.AttributeValue
 
-		// DynamoDB BatchWriteItem supports up to 25 items per batch
-		batchSize := 25
+		for {
+			page, lastKey, err := readPage(m.client, params, startKey)
+			if err != nil {
+				return matchingKeysLoadedMsg{err: err}
+			}
+			for _, item := range page {
+				keys = append(keys, itemKey(item, keySchema))
+			}
 
-		for i := 0; i < len(m.items); i += batchSize {
-			end := i + batchSize
-			if end > len(m.items) {
-				end = len(m.items)
+			if len(lastKey) == 0 {
+				return matchingKeysLoadedMsg{keys: keys}
 			}
+			startKey = lastKey
+		}
+	}
+}
 
-			batch := m.items[i:end]
+// startDeleteMatching counts the items matching the active filters before asking for confirmation
+func (m Model) startDeleteMatching() (tea.Model, tea.Cmd) {
+	if m.partiqlStatement != "" {
+		return m, messages.ShowToast("Leave PartiQL results (Ctrl+X in the console) to delete by filter", messages.ToastWarning)
+	}
+	if len(m.activeFilters) == 0 && m.activeQuery == nil {
+		return m, messages.ShowToast("Add a filter or key query first; use e on the table list to empty the table", messages.ToastWarning)
+	}
 
-			// Build delete requests
-			var writeRequests []types.WriteRequest
-			for _, item := range batch {
-				key := make(map[string]types.AttributeValue)
-				key[keySchema.PartitionKey] = item[keySchema.PartitionKey]
-				if keySchema.SortKey != "" {
-					key[keySchema.SortKey] = item[keySchema.SortKey]
-				}
+	m.deleteMatching = true
+	m.state = stateLoading
+	return m, m.loadMatchingKeys()
+}
 
-				writeRequests = append(writeRequests, types.WriteRequest{
-					DeleteRequest: &types.DeleteRequest{
-						Key: key,
-					},
-				})
-			}
+func (m Model) handleMatchingKeysLoaded(msg matchingKeysLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.deleteMatching = false
+		m.err = msg.err
+		m.state = stateItemList
+		return m, nil
+	}
 
-			// Execute batch delete
-			_, err := m.client.BatchWriteItem(context.Background(), &dynamodb.BatchWriteItemInput{
-				RequestItems: map[string][]types.WriteRequest{
-					m.selectedTable: writeRequests,
-				},
-			})
+	if len(msg.keys) == 0 {
+		m.deleteMatching = false
+		m.state = stateItemList
+		return m, messages.ShowToast("No items match the current filters", messages.ToastInfo)
+	}
 
-			if err != nil {
-				return deleteCompleteMsg{
-					deleted: deleted,
-					err:     fmt.Errorf("failed to delete batch: %w", err),
-				}
-			}
+	m.deleteKeys = msg.keys
+	m.confirmInput = newDeleteConfirmInput()
+	m.state = stateDeleteConfirm
+	return m, nil
+}
+
+// startEmptyTable deletes every item of the selected table, backing each page up first
+func (m Model) startEmptyTable() (tea.Model, tea.Cmd) {
+	backup, err := m.newBackupWriter("empty-table")
+	if err != nil {
+		m.err = fmt.Errorf("failed to create backup, nothing was deleted: %w", err)
+		m.state = stateTableList
+		return m, nil
+	}
+
+	keySchema := m.tableKeys[m.selectedTable]
+	return m.startBatchJob(batchJob{
+		kind:      jobEmptyTable,
+		tableName: m.selectedTable,
+		total:     m.emptyCount,
+		pager:     m.readPager(readParams{TableName: m.selectedTable, Keys: keySchema}),
+		requests: func(items []map[string]types.AttributeValue) []types.WriteRequest {
+			return deleteRequests(items, keySchema)
+		},
+		backup: backup,
+	})
+}
+
+// startDeleteMatchingJob deletes everything the active query and filters
+// match. Only the keys counted for the confirmation are deleted: their items
+// are read from the base table page by page, so each page is backed up in
+// full right before it is deleted.
+func (m Model) startDeleteMatchingJob() (tea.Model, tea.Cmd) {
+	backup, err := m.newBackupWriter("delete-matching")
+	if err != nil {
+		m.err = fmt.Errorf("failed to create backup, nothing was deleted: %w", err)
+		m.deleteMatching = false
+		m.deleteKeys = nil
+		m.state = stateItemList
+		return m, nil
+	}
+
+	keySchema := m.tableKeys[m.selectedTable]
+	m.deleteGone = new(int)
+	return m.startBatchJob(batchJob{
+		kind:      jobDeleteMatching,
+		tableName: m.selectedTable,
+		total:     len(m.deleteKeys),
+		pager:     keyPager(m.client, m.selectedTable, keySchema, m.deleteKeys, m.deleteGone),
+		requests: func(items []map[string]types.AttributeValue) []types.WriteRequest {
+			return deleteRequests(items, keySchema)
+		},
+		backup: backup,
+	})
+}
 
-			deleted += len(batch)
+// keyPager reads the items of the given keys from the base table, a batch per
+// page. Keys whose item no longer exists are left out and counted in gone,
+// which the UI only reads once the job is done.
+func keyPager(client *dynamodb.Client, tableName string, keySchema TableKeySchema, keys []map[string]types.AttributeValue, gone *int) itemPager {
+	next := 0
+	return func(ctx context.Context) ([]map[string]types.AttributeValue, bool, error) {
+		batch := keys[next:min(next+batchGetSize, len(keys))]
+		found, err := getExistingItems(ctx, client, tableName, keySchema, batch)
+		if err != nil {
+			return nil, false, err
 		}
+		next += len(batch)
 
-		return deleteCompleteMsg{
-			deleted: deleted,
-			err:     nil,
+		items := make([]map[string]types.AttributeValue, 0, len(batch))
+		for _, key := range batch {
+			if item, ok := found[keyString(key, keySchema)]; ok {
+				items = append(items, item)
+			} else {
+				*gone++
+			}
 		}
+		return items, next < len(keys), nil
 	}
 }
 
@@ -87,14 +183,20 @@ func (m Model) updateDeleteConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "esc":
 			m.state = stateTableList
+			if m.deleteMatching {
+				m.deleteMatching = false
+				m.deleteKeys = nil
+				m.state = stateItemList
+			}
 			m.confirmInput.SetValue("")
 			return m, nil
 
 		case "enter":
 			if m.confirmInput.Value() == m.selectedTable {
-				m.state = stateDeleting
-				m.deleteTotal = len(m.items)
-				return m, m.deleteAllItems()
+				if m.deleteMatching {
+					return m.startDeleteMatchingJob()
+				}
+				return m.startEmptyTable()
 			}
 			return m, nil
 		}
@@ -103,3 +205,70 @@ func (m Model) updateDeleteConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.confirmInput, cmd = m.confirmInput.Update(msg)
 	return m, cmd
 }
+
+func (m Model) handleBatchDone(msg batchDoneMsg) (tea.Model, tea.Cmd) {
+	m.batchCancel = nil
+	m.batchUpdates = nil
+	m.state = stateTableList
+
+	verb := "deleted"
+	gone := 0
+	var cmd tea.Cmd
+	switch m.batchJob.kind {
+	case jobDeleteMatching:
+		// Deleting by filter goes back to the (reloaded) item list
+		gone = *m.deleteGone
+		m.deleteMatching = false
+		m.deleteKeys = nil
+		m.deleteGone = nil
+		m.state = stateLoading
+		cmd = m.loadItems(m.activeFilters)
+	case jobRestore:
+		verb = "restored"
+	case jobCopy:
+		verb = "copied"
+		report := m.copyReport
+		report.written = msg.written
+		m, cmd = m.finishCopy()
+		msg.backupPath = report.backupPath
+		msg.backupErr = report.backupErr
+		if report.conflicts > 0 && msg.err == nil && !msg.cancelled {
+			// The conflict report says how many were copied
+			return m, cmd
+		}
+	case jobImport:
+		// Show the imported items in the (reloaded) item list
+		verb = "imported"
+		m.state = stateLoading
+		cmd = m.loadItems(m.activeFilters)
+	}
+
+	switch {
+	case msg.err != nil:
+		m.err = fmt.Errorf("%s %d items before failing: %w", verb, msg.written, msg.err)
+		if msg.backupPath != "" {
+			m.err = fmt.Errorf("%w (backup: %s)", m.err, msg.backupPath)
+		}
+		if msg.backupErr != nil {
+			m.err = fmt.Errorf("%w; the backup is incomplete: %w", m.err, msg.backupErr)
+		}
+		return m, cmd
+	case msg.backupErr != nil:
+		m.err = fmt.Errorf("%d items %s, but the backup is incomplete: %w", msg.written, verb, msg.backupErr)
+		return m, cmd
+	case msg.cancelled:
+		return m, tea.Batch(cmd, messages.ShowToast(fmt.Sprintf("Cancelled after %d items %s", msg.written, verb), messages.ToastWarning))
+	}
+
+	text := fmt.Sprintf("%d items %s", msg.written, verb)
+	if msg.retries > 0 {
+		text += fmt.Sprintf(" (%d retries)", msg.retries)
+	}
+	if gone > 0 {
+		text += fmt.Sprintf(" • %d confirmed items were already gone", gone)
+	}
+	if msg.backupPath != "" {
+		text += " • backup: " + msg.backupPath
+	}
+	return m, tea.Batch(cmd, messages.ShowToast(text, messages.ToastSuccess))
+}
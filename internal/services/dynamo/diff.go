@@ -0,0 +1,46 @@
+package dynamo
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// attributeDiff is one changed attribute between two versions of an item.
+// Old is nil for added attributes and New is nil for removed ones.
+type attributeDiff struct {
+	Name string
+	Old  types.AttributeValue
+	New  types.AttributeValue
+}
+
+// diffItems lists the attributes that differ between two items, sorted by name
+func diffItems(oldItem, newItem map[string]types.AttributeValue) []attributeDiff {
+	names := make(map[string]types.AttributeValue, len(oldItem)+len(newItem))
+	for k, v := range oldItem {
+		names[k] = v
+	}
+	for k, v := range newItem {
+		names[k] = v
+	}
+
+	var diffs []attributeDiff
+	for _, name := range sortedKeys(names) {
+		oldValue, inOld := oldItem[name]
+		newValue, inNew := newItem[name]
+		if inOld && inNew && attributeValuesEqual(oldValue, newValue) {
+			continue
+		}
+		diffs = append(diffs, attributeDiff{Name: name, Old: oldValue, New: newValue})
+	}
+	return diffs
+}
+
+// compactDynamoJSON renders a value as single-line DynamoDB JSON, so type changes show up in diffs
+func compactDynamoJSON(av types.AttributeValue) string {
+	data, err := json.Marshal(attributeValueToDynamoJSON(av))
+	if err != nil {
+		return "?"
+	}
+	return string(data)
+}
@@ -0,0 +1,409 @@
+package dynamo
+
+import (
+	"cirrus/internal/messages"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type itemSavedMsg struct {
+	item       map[string]types.AttributeValue
+	moved      map[string]types.AttributeValue // Key the item had before a move
+	backupPath string                          // Backup of the item as it was before the edit
+	err        error
+}
+
+type itemReloadedMsg struct {
+	item map[string]types.AttributeValue // Nil when the item no longer exists
+	err  error
+}
+
+func newItemEditorInput(width, height int) textarea.Model {
+	ta := textarea.New()
+	ta.ShowLineNumbers = true
+	ta.CharLimit = 0
+	ta.SetWidth(max(width-4, 40))
+	ta.SetHeight(max(height-12, 10))
+	ta.Focus()
+	return ta
+}
+
+// startItemEdit re-reads a row's full item from the base table before editing it.
+// Rows from an index or a PartiQL SELECT may only carry some attributes, and
+// saving them would drop the rest.
+func (m Model) startItemEdit(row map[string]types.AttributeValue) (tea.Model, tea.Cmd) {
+	if cmd := m.refusePartiQLRows("edit"); cmd != nil {
+		return m, cmd
+	}
+
+	if m.state != stateItemEditor {
+		m.previousState = m.state
+	}
+	m.state = stateLoading
+	return m, m.reloadItem(itemKey(row, m.tableKeys[m.selectedTable]))
+}
+
+// reloadItem reads an item by key with a consistent read
+func (m Model) reloadItem(key map[string]types.AttributeValue) tea.Cmd {
+	tableName := m.selectedTable
+	return func() tea.Msg {
+		result, err := m.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+			TableName:      aws.String(tableName),
+			Key:            key,
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return itemReloadedMsg{err: err}
+		}
+		return itemReloadedMsg{item: result.Item}
+	}
+}
+
+func (m Model) handleItemReloaded(msg itemReloadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.state = m.previousState
+		return m, nil
+	}
+	if msg.item == nil {
+		m.state = m.previousState
+		return m, messages.ShowToast("The item no longer exists; refresh the list", messages.ToastWarning)
+	}
+	return m.openItemEditor(msg.item)
+}
+
+// openItemEditor starts editing an item, or creating one when original is nil.
+// Existing items come from startItemEdit, so original holds every attribute.
+func (m Model) openItemEditor(original map[string]types.AttributeValue) (tea.Model, tea.Cmd) {
+	m.editOriginal = original
+	m.editConditional = true
+	m.editErr = nil
+	m.editorInput = newItemEditorInput(m.Width, m.Height)
+
+	item := original
+	m.editorFormat = formatDynamoJSON
+	if original == nil {
+		// Start new items from their key attributes
+		keys := m.tableKeys[m.selectedTable]
+		item = map[string]types.AttributeValue{}
+		for name, t := range map[string]types.ScalarAttributeType{keys.PartitionKey: keys.PartitionKeyType, keys.SortKey: keys.SortKeyType} {
+			if name == "" {
+				continue
+			}
+			if t == types.ScalarAttributeTypeN {
+				item[name] = &types.AttributeValueMemberN{Value: "0"}
+			} else {
+				item[name] = &types.AttributeValueMemberS{Value: ""}
+			}
+		}
+		m.editorFormat = formatPlainJSON
+	}
+
+	data, err := encodeItem(item, m.editorFormat, true)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.editorInput.SetValue(string(data))
+
+	if m.state != stateItemEditor && m.state != stateLoading {
+		m.previousState = m.state
+	}
+	m.state = stateItemEditor
+	return m, textarea.Blink
+}
+
+// validateItemKeys checks that an item carries the table's key attributes with the right types
+func validateItemKeys(item map[string]types.AttributeValue, keys TableKeySchema) error {
+	check := func(name string, t types.ScalarAttributeType) error {
+		av, ok := item[name]
+		if !ok {
+			return fmt.Errorf("missing key attribute %q", name)
+		}
+
+		var valid bool
+		switch t {
+		case types.ScalarAttributeTypeN:
+			_, valid = av.(*types.AttributeValueMemberN)
+		case types.ScalarAttributeTypeB:
+			_, valid = av.(*types.AttributeValueMemberB)
+		default:
+			var s *types.AttributeValueMemberS
+			s, valid = av.(*types.AttributeValueMemberS)
+			if valid && s.Value == "" {
+				return fmt.Errorf("key attribute %q can't be empty", name)
+			}
+		}
+		if !valid {
+			return fmt.Errorf("key attribute %q must be of type %s", name, t)
+		}
+		return nil
+	}
+
+	if err := check(keys.PartitionKey, keys.PartitionKeyType); err != nil {
+		return err
+	}
+	if keys.SortKey != "" {
+		return check(keys.SortKey, keys.SortKeyType)
+	}
+	return nil
+}
+
+// keyChanged reports whether an edit moved the item to a different primary key
+func (m Model) keyChanged() bool {
+	return m.editOriginal != nil && !sameKey(m.editOriginal, m.editItem, m.tableKeys[m.selectedTable])
+}
+
+func (m Model) updateItemEditor(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.state = m.previousState
+			return m, nil
+
+		case "ctrl+o":
+			// Switch between DynamoDB JSON and plain JSON
+			item, _, err := decodeItem([]byte(m.editorInput.Value()))
+			if err != nil {
+				m.editErr = err
+				return m, nil
+			}
+
+			format := formatDynamoJSON
+			if m.editorFormat == formatDynamoJSON {
+				format = formatPlainJSON
+			}
+			if format == formatPlainJSON && hasTypedValues(item) {
+				m.editErr = fmt.Errorf("the item has sets or binary values, which plain JSON would turn into lists and strings; keep editing it as DynamoDB JSON")
+				return m, nil
+			}
+			data, err := encodeItem(item, format, true)
+			if err != nil {
+				m.editErr = err
+				return m, nil
+			}
+			m.editorFormat = format
+			m.editorInput.SetValue(string(data))
+			m.editErr = nil
+			return m, nil
+
+		case "ctrl+s":
+			item, format, err := decodeItem([]byte(m.editorInput.Value()))
+			if err == nil {
+				err = validateItemKeys(item, m.tableKeys[m.selectedTable])
+			}
+			if err != nil {
+				m.editErr = err
+				return m, nil
+			}
+
+			m.editorFormat = format
+			m.editItem = item
+			m.editDiff = diffItems(m.editOriginal, item)
+			m.editErr = nil
+			if len(m.editDiff) == 0 {
+				m.state = m.previousState
+				return m, messages.ShowToast("No changes to save", messages.ToastInfo)
+			}
+			m.state = stateItemSaveConfirm
+			return m, nil
+		}
+	}
+
+	m.editorInput, cmd = m.editorInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) updateItemSaveConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		m.state = stateLoading
+		return m, m.putItem(m.editItem, m.editOriginal, m.editConditional)
+
+	case "c":
+		m.editConditional = !m.editConditional
+		return m, nil
+
+	case "n", "esc":
+		m.state = stateItemEditor
+		return m, nil
+	}
+	return m, nil
+}
+
+// putItem writes an item. With conditional set, an edit only succeeds if every
+// attribute still has the value it had when loaded, and a new item only if its
+// key isn't taken yet. Attributes added by someone else since the load are not
+// detected. An edit that changes the key moves the item instead.
+func (m Model) putItem(item, original map[string]types.AttributeValue, conditional bool) tea.Cmd {
+	tableName := m.selectedTable
+	keys := m.tableKeys[tableName]
+
+	if original != nil && !sameKey(original, item, keys) {
+		return m.moveItem(item, original, conditional)
+	}
+
+	return func() tea.Msg {
+		input := &dynamodb.PutItemInput{
+			TableName: aws.String(tableName),
+			Item:      item,
+		}
+
+		if conditional && original == nil {
+			// Don't overwrite an existing item when creating one
+			input.ConditionExpression = aws.String("attribute_not_exists(#pk)")
+			input.ExpressionAttributeNames = map[string]string{"#pk": keys.PartitionKey}
+		} else if conditional {
+			input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues = unchangedCondition(original)
+		}
+
+		var backupPath string
+		if original != nil {
+			path, err := m.backupItems("edit", []map[string]types.AttributeValue{original})
+			if err != nil {
+				return itemSavedMsg{err: err}
+			}
+			backupPath = path
+		}
+
+		_, err := m.client.PutItem(context.TODO(), input)
+		if err != nil {
+			var conditionErr *types.ConditionalCheckFailedException
+			if errors.As(err, &conditionErr) {
+				if original == nil {
+					err = fmt.Errorf("an item with this key already exists")
+				} else {
+					err = fmt.Errorf("the item changed since it was loaded; refresh and edit it again")
+				}
+			}
+			return itemSavedMsg{err: err}
+		}
+
+		return itemSavedMsg{item: item, backupPath: backupPath}
+	}
+}
+
+// moveItem writes an edited item under its new key and deletes the original in
+// one transaction, so neither happens without the other. The new key must not
+// be taken; the original must still exist, and with conditional set must also
+// be unchanged since it was loaded.
+func (m Model) moveItem(item, original map[string]types.AttributeValue, conditional bool) tea.Cmd {
+	tableName := m.selectedTable
+	keys := m.tableKeys[tableName]
+
+	return func() tea.Msg {
+		remove := &types.Delete{
+			TableName:                aws.String(tableName),
+			Key:                      itemKey(original, keys),
+			ConditionExpression:      aws.String("attribute_exists(#pk)"),
+			ExpressionAttributeNames: map[string]string{"#pk": keys.PartitionKey},
+		}
+		if conditional {
+			remove.ConditionExpression, remove.ExpressionAttributeNames, remove.ExpressionAttributeValues = unchangedCondition(original)
+		}
+
+		backupPath, err := m.backupItems("edit", []map[string]types.AttributeValue{original})
+		if err != nil {
+			return itemSavedMsg{err: err}
+		}
+
+		_, err = m.client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{Put: &types.Put{
+					TableName:                aws.String(tableName),
+					Item:                     item,
+					ConditionExpression:      aws.String("attribute_not_exists(#pk)"),
+					ExpressionAttributeNames: map[string]string{"#pk": keys.PartitionKey},
+				}},
+				{Delete: remove},
+			},
+		})
+		if err != nil {
+			// Reasons are listed in the order of the transaction's items
+			var canceled *types.TransactionCanceledException
+			if errors.As(err, &canceled) && len(canceled.CancellationReasons) == 2 {
+				switch {
+				case aws.ToString(canceled.CancellationReasons[0].Code) == "ConditionalCheckFailed":
+					err = fmt.Errorf("an item with the new key already exists")
+				case aws.ToString(canceled.CancellationReasons[1].Code) == "ConditionalCheckFailed":
+					err = fmt.Errorf("the item changed or was deleted since it was loaded; refresh and edit it again")
+				}
+			}
+			return itemSavedMsg{err: err}
+		}
+
+		return itemSavedMsg{item: item, moved: itemKey(original, keys), backupPath: backupPath}
+	}
+}
+
+// unchangedCondition requires every attribute of the original to still have its loaded value
+func unchangedCondition(original map[string]types.AttributeValue) (*string, map[string]string, map[string]types.AttributeValue) {
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+	var parts []string
+	for i, name := range sortedKeys(original) {
+		nameKey := fmt.Sprintf("#c%d", i)
+		valueKey := fmt.Sprintf(":c%d", i)
+		names[nameKey] = name
+		values[valueKey] = original[name]
+		parts = append(parts, fmt.Sprintf("%s = %s", nameKey, valueKey))
+	}
+	return aws.String(strings.Join(parts, " AND ")), names, values
+}
+
+func (m Model) handleItemSaved(msg itemSavedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		// Back to the editor so nothing typed is lost
+		m.err = msg.err
+		m.state = stateItemEditor
+		return m, nil
+	}
+
+	// Replace the row in place, or add it when the key is new. A moved item
+	// takes the place of its original row.
+	keys := m.tableKeys[m.selectedTable]
+	row := msg.item
+	toast := "Item saved"
+	if msg.moved != nil {
+		row = msg.moved
+		toast = "Item moved to its new key"
+		delete(m.markedItems, keyString(msg.moved, keys))
+	}
+	idx := -1
+	for i, item := range m.items {
+		if sameKey(item, row, keys) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		m.items = append(m.items, msg.item)
+		idx = len(m.items) - 1
+	} else {
+		m.items[idx] = msg.item
+	}
+
+	m.columnTypes = sampleColumnTypes([]map[string]types.AttributeValue{msg.item}, m.columnTypes)
+	m.rebuildItemTable(idx)
+	m.selectedIdx = idx
+	m.editOriginal = nil
+	m.editItem = nil
+	m.editDiff = nil
+	m.state = stateItemDetail
+
+	if msg.backupPath != "" {
+		toast += " • backup: " + msg.backupPath
+	}
+	return m, messages.ShowToast(toast, messages.ToastSuccess)
+}
@@ -0,0 +1,589 @@
+package dynamo
+
+import (
+	"bufio"
+	"cirrus/internal/messages"
+	"cirrus/internal/styles"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Export file formats
+const (
+	exportJSONL      = "jsonl"         // One plain JSON object per line
+	exportJSON       = "json"          // A single plain JSON array
+	exportDynamoJSON = "dynamodb-json" // One DynamoDB JSON object per line, as used by backups and import
+	exportCSV        = "csv"
+)
+
+var exportFormats = []string{exportJSONL, exportJSON, exportDynamoJSON, exportCSV}
+
+// exportExtension returns the file extension for an export format
+func exportExtension(format string) string {
+	switch format {
+	case exportJSON:
+		return ".json"
+	case exportDynamoJSON:
+		return ".dynamodb.jsonl"
+	case exportCSV:
+		return ".csv"
+	}
+	return ".jsonl"
+}
+
+// ExportOptions describes where and how to write an export
+type ExportOptions struct {
+	Path    string
+	Format  string
+	Flatten bool // CSV only: expand nested maps and lists into dotted columns instead of JSON-encoding them
+}
+
+type ExportSubmittedMsg struct {
+	Options ExportOptions
+}
+
+type exportProgressMsg struct {
+	written int
+}
+
+type exportDoneMsg struct {
+	path      string
+	written   int
+	cancelled bool
+	err       error
+}
+
+type ExportFormModel struct {
+	tableName   string
+	formatIdx   int
+	flatten     bool
+	pathInput   textinput.Model
+	pathEdited  bool // Keep a typed path when the format changes
+	description string
+	err         error
+}
+
+// NewExportFormModel creates the export form; description says what is being exported
+func NewExportFormModel(tableName, description string) ExportFormModel {
+	m := ExportFormModel{
+		tableName:   tableName,
+		description: description,
+	}
+
+	m.pathInput = textinput.New()
+	m.pathInput.Placeholder = "Output file"
+	m.pathInput.Width = 60
+	m.pathInput.Focus()
+	m.pathInput.SetValue(m.defaultPath())
+	return m
+}
+
+func (m ExportFormModel) format() string {
+	return exportFormats[m.formatIdx]
+}
+
+func (m ExportFormModel) defaultPath() string {
+	return fmt.Sprintf("%s-%s%s", m.tableName, time.Now().Format("20060102-150405"), exportExtension(m.format()))
+}
+
+func (m ExportFormModel) Update(msg tea.Msg) (ExportFormModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "tab", "shift+tab":
+			if msg.String() == "tab" {
+				m.formatIdx = (m.formatIdx + 1) % len(exportFormats)
+			} else {
+				m.formatIdx = (m.formatIdx + len(exportFormats) - 1) % len(exportFormats)
+			}
+			if !m.pathEdited {
+				m.pathInput.SetValue(m.defaultPath())
+			}
+			return m, nil
+
+		case "ctrl+t":
+			m.flatten = !m.flatten
+			return m, nil
+
+		case "enter":
+			path := strings.TrimSpace(m.pathInput.Value())
+			if path == "" {
+				m.err = fmt.Errorf("enter an output file")
+				return m, nil
+			}
+			path, err := expandHome(path)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			if _, err := os.Stat(path); err == nil {
+				m.err = fmt.Errorf("%s already exists", path)
+				return m, nil
+			}
+
+			opts := ExportOptions{Path: path, Format: m.format(), Flatten: m.flatten}
+			return m, func() tea.Msg {
+				return ExportSubmittedMsg{Options: opts}
+			}
+		}
+	}
+
+	before := m.pathInput.Value()
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	if m.pathInput.Value() != before {
+		m.pathEdited = true
+		m.err = nil
+	}
+	return m, cmd
+}
+
+func (m ExportFormModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("💾 Export %s", m.tableName)))
+	b.WriteString("\n\n")
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	b.WriteString(helpStyle.Render(m.description))
+	b.WriteString("\n\n")
+
+	var formats []string
+	for i, f := range exportFormats {
+		if i == m.formatIdx {
+			formats = append(formats, styles.SelectedStyle.Render("["+f+"]"))
+		} else {
+			formats = append(formats, " "+f+" ")
+		}
+	}
+	b.WriteString("Format: " + strings.Join(formats, " ") + "\n")
+
+	if m.format() == exportCSV {
+		nested := "JSON-encoded"
+		if m.flatten {
+			nested = "flattened into dotted columns"
+		}
+		b.WriteString(fmt.Sprintf("Nested values: %s\n", styles.SelectedStyle.Render(nested)))
+	}
+
+	b.WriteString(fmt.Sprintf("File:   %s\n", m.pathInput.View()))
+
+	if m.err != nil {
+		b.WriteString("\n")
+		b.WriteString(styles.ErrorStyle.Render(m.err.Error()))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	help := "Tab: Format • "
+	if m.format() == exportCSV {
+		help += "Ctrl+T: Flatten/JSON-encode nested values • "
+	}
+	help += "Enter: Export • Esc: Cancel"
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+// expandHome resolves a leading ~/ in a path
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[2:]), nil
+}
+
+// openExportForm asks where and how to export the current result set
+func (m Model) openExportForm() (tea.Model, tea.Cmd) {
+	source := "every item"
+	switch {
+	case m.partiqlStatement != "":
+		source = "every result of the PartiQL statement"
+	case m.activeQuery != nil || len(m.activeFilters) > 0:
+		source = "every item matching the current query and filters"
+	}
+
+	description := fmt.Sprintf("Exports %s, reading all pages", source)
+	if cols := m.config.GetTableColumns(m.selectedTable); len(cols) > 0 {
+		description += fmt.Sprintf(", limited to the key attributes and the %d saved columns (DynamoDB JSON keeps whole items)", len(cols))
+	}
+	if m.partiqlStatement != "" {
+		// PartiQL results aren't tied to base-table items that could be read again
+		description += "; results only hold the attributes the statement selects"
+	}
+
+	m.exportForm = NewExportFormModel(m.selectedTable, description)
+	m.state = stateExportForm
+	return m, nil
+}
+
+func (m Model) updateExportForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "esc" {
+		m.state = stateItemList
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.exportForm, cmd = m.exportForm.Update(msg)
+	return m, cmd
+}
+
+// resultPager pages through the full result set behind the item list:
+// the PartiQL statement, or the query/scan with the active filters. Rows of an
+// index that doesn't project every attribute are read again from the base table.
+func (m Model) resultPager() itemPager {
+	if m.partiqlStatement != "" {
+		return m.partiqlPager(m.partiqlStatement)
+	}
+	pager := m.readPager(m.readParams(m.activeFilters))
+	if m.rowsArePartial() {
+		pager = fullItemPager(m.client, m.selectedTable, m.tableKeys[m.selectedTable], pager)
+	}
+	return pager
+}
+
+// startExport writes the full result set in the background and listens for its progress
+func (m Model) startExport(opts ExportOptions) (tea.Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan tea.Msg, 1)
+
+	m.exportCancel = cancel
+	m.exportUpdates = updates
+	m.exportWritten = 0
+	m.state = stateExporting
+
+	pager := m.resultPager()
+	keys := m.tableKeys[m.selectedTable]
+	columns := m.config.GetTableColumns(m.selectedTable)
+
+	return m, tea.Batch(
+		func() tea.Msg {
+			written, err := runExport(ctx, pager, opts, keys, columns, updates)
+			updates <- exportDoneMsg{
+				path:      opts.Path,
+				written:   written,
+				cancelled: err != nil && ctx.Err() != nil,
+				err:       err,
+			}
+			close(updates)
+			return nil
+		},
+		waitForUpdate(updates),
+	)
+}
+
+// runExport pages through the results and writes them to opts.Path. A failed
+// export removes the file it created, but never a file that was already there.
+func runExport(
+	ctx context.Context,
+	pager itemPager,
+	opts ExportOptions,
+	keys TableKeySchema,
+	columns []string,
+	updates chan<- tea.Msg,
+) (written int, err error) {
+	file, err := os.OpenFile(opts.Path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(opts.Path)
+		}
+	}()
+	defer file.Close()
+
+	out := bufio.NewWriter(file)
+
+	// DynamoDB JSON exports can be restored, so they always hold whole items
+	if opts.Format == exportDynamoJSON {
+		columns = nil
+	}
+	columns = withKeyColumns(columns, keys)
+
+	// CSV needs every column before the first row, so items are spooled to a
+	// temporary file first instead of being held in memory
+	var spool *os.File
+	var spoolOut *bufio.Writer
+	if opts.Format == exportCSV {
+		if spool, err = os.CreateTemp("", "cirrus-export-*.jsonl"); err != nil {
+			return 0, err
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+		spoolOut = bufio.NewWriter(spool)
+	}
+
+	if opts.Format == exportJSON {
+		out.WriteString("[\n")
+	}
+
+	for {
+		items, more, err := pager(ctx)
+		if err != nil {
+			return written, err
+		}
+
+		for _, item := range items {
+			item = projectItem(item, columns)
+
+			var line []byte
+			switch opts.Format {
+			case exportDynamoJSON, exportCSV:
+				line, err = encodeItem(item, formatDynamoJSON, false)
+			default:
+				line, err = encodeItem(item, formatPlainJSON, false)
+			}
+			if err != nil {
+				return written, err
+			}
+
+			switch opts.Format {
+			case exportCSV:
+				spoolOut.Write(line)
+				spoolOut.WriteByte('\n')
+			case exportJSON:
+				if written > 0 {
+					out.WriteString(",\n")
+				}
+				out.WriteString("  ")
+				out.Write(line)
+			default:
+				out.Write(line)
+				out.WriteByte('\n')
+			}
+			written++
+		}
+
+		// Drop the update if the UI hasn't taken the previous one yet
+		select {
+		case updates <- exportProgressMsg{written: written}:
+		default:
+		}
+
+		if !more {
+			break
+		}
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+	}
+
+	switch opts.Format {
+	case exportJSON:
+		out.WriteString("\n]\n")
+	case exportCSV:
+		if err := spoolOut.Flush(); err != nil {
+			return written, err
+		}
+		if err := writeCSV(out, spool, opts.Flatten, keys, columns); err != nil {
+			return written, err
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		return written, err
+	}
+	return written, file.Close()
+}
+
+// withKeyColumns puts the key attributes in front of the saved columns, so a
+// projected export still identifies its items. No columns means every attribute.
+func withKeyColumns(columns []string, keys TableKeySchema) []string {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	var out []string
+	for _, key := range []string{keys.PartitionKey, keys.SortKey} {
+		if key != "" && !slices.Contains(columns, key) {
+			out = append(out, key)
+		}
+	}
+	return append(out, columns...)
+}
+
+// projectItem keeps only the saved columns of an item. Nested path columns
+// are stored under their path, the way they show up in the item table.
+func projectItem(item map[string]types.AttributeValue, columns []string) map[string]types.AttributeValue {
+	if len(columns) == 0 {
+		return item
+	}
+
+	projected := make(map[string]types.AttributeValue, len(columns))
+	for _, col := range columns {
+		if av, ok := resolveAttributePath(item, col); ok {
+			projected[col] = av
+		}
+	}
+	return projected
+}
+
+// writeCSV converts spooled DynamoDB JSON lines to CSV. Columns are the saved
+// columns if there are any, otherwise every attribute seen with the key attributes first.
+func writeCSV(out *bufio.Writer, spool *os.File, flatten bool, keys TableKeySchema, columns []string) error {
+	readSpool := func(fn func(row map[string]string)) error {
+		if _, err := spool.Seek(0, 0); err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(spool)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxBackupLine)
+		for scanner.Scan() {
+			item, _, err := decodeItem(scanner.Bytes())
+			if err != nil {
+				return err
+			}
+			fn(csvRow(item, flatten))
+		}
+		return scanner.Err()
+	}
+
+	header := columns
+	if len(header) == 0 || flatten {
+		seen := make(map[string]bool)
+		if err := readSpool(func(row map[string]string) {
+			for col := range row {
+				seen[col] = true
+			}
+		}); err != nil {
+			return err
+		}
+
+		header = nil
+		for _, key := range []string{keys.PartitionKey, keys.SortKey} {
+			if key != "" && seen[key] {
+				header = append(header, key)
+				delete(seen, key)
+			}
+		}
+		rest := make([]string, 0, len(seen))
+		for col := range seen {
+			rest = append(rest, col)
+		}
+		sort.Strings(rest)
+		header = append(header, rest...)
+	}
+
+	w := csv.NewWriter(out)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(header))
+	if err := readSpool(func(row map[string]string) {
+		for i, col := range header {
+			record[i] = row[col]
+		}
+		w.Write(record)
+	}); err != nil {
+		return err
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// csvRow turns an item into CSV cells by column name
+func csvRow(item map[string]types.AttributeValue, flatten bool) map[string]string {
+	row := make(map[string]string, len(item))
+	for name, av := range item {
+		if flatten {
+			flattenValue(name, av, row)
+		} else {
+			row[name] = csvValue(av)
+		}
+	}
+	return row
+}
+
+// flattenValue expands maps into name.key and lists into name[i] cells
+func flattenValue(name string, av types.AttributeValue, row map[string]string) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberM:
+		if len(v.Value) == 0 {
+			row[name] = "{}"
+		}
+		for key, child := range v.Value {
+			flattenValue(name+"."+key, child, row)
+		}
+	case *types.AttributeValueMemberL:
+		if len(v.Value) == 0 {
+			row[name] = "[]"
+		}
+		for i, child := range v.Value {
+			flattenValue(fmt.Sprintf("%s[%d]", name, i), child, row)
+		}
+	default:
+		row[name] = csvValue(av)
+	}
+}
+
+// csvValue renders a single cell; maps, lists and sets are JSON-encoded
+func csvValue(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value
+	case *types.AttributeValueMemberN:
+		return v.Value
+	case *types.AttributeValueMemberBOOL:
+		if v.Value {
+			return "true"
+		}
+		return "false"
+	case *types.AttributeValueMemberNULL:
+		return ""
+	case *types.AttributeValueMemberB:
+		return base64.StdEncoding.EncodeToString(v.Value)
+	}
+
+	data, err := json.Marshal(attributeValueToPlain(av))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func (m Model) updateExporting(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "x":
+		if m.exportCancel != nil {
+			m.exportCancel()
+			m.exportCancel = nil
+		}
+	}
+	return m, nil
+}
+
+func (m Model) handleExportDone(msg exportDoneMsg) (tea.Model, tea.Cmd) {
+	m.exportCancel = nil
+	m.exportUpdates = nil
+	m.state = stateItemList
+
+	switch {
+	case msg.cancelled:
+		return m, messages.ShowToast("Export cancelled", messages.ToastWarning)
+	case msg.err != nil:
+		m.err = fmt.Errorf("export failed after %d items: %w", msg.written, msg.err)
+		return m, nil
+	}
+	return m, messages.ShowToast(fmt.Sprintf("Exported %d items to %s", msg.written, msg.path), messages.ToastSuccess)
+}
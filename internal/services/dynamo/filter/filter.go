@@ -2,6 +2,7 @@ package filter
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -9,18 +10,153 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// Value types a condition can be compared as
+const (
+	ValueTypeString = "S"
+	ValueTypeNumber = "N"
+	ValueTypeBool   = "BOOL"
+	ValueTypeNull   = "NULL"
+	ValueTypeAuto   = "AUTO" // Inferred from sampled items of the column
+)
+
+// Groups combine child conditions
+const (
+	GroupAnd = "and"
+	GroupOr  = "or"
+)
+
+// FilterCondition is a node of a filter expression tree. Leaves compare a
+// column; group nodes combine their Children with and/or. A list of
+// conditions is always combined with AND.
 type FilterCondition struct {
-	Column   string `json:"column"`
-	Operator string `json:"operator"` // ==, !=, contains, startswith
-	Value    string `json:"value"`
+	Column    string   `json:"column"`
+	Operator  string   `json:"operator"` // See operators for the supported set
+	Value     string   `json:"value"`
+	Values    []string `json:"values,omitempty"`     // Operands of between (low, high) and in
+	ValueType string   `json:"value_type,omitempty"` // Empty for filters saved before typing, treated as S
+
+	Group    string            `json:"group,omitempty"` // and, or; empty for a leaf
+	Children []FilterCondition `json:"children,omitempty"`
+	Negate   bool              `json:"not,omitempty"`
+}
+
+// IsGroup reports whether the condition combines children instead of testing a column
+func (c FilterCondition) IsGroup() bool {
+	return c.Group != ""
+}
+
+// Type returns the condition's value type, defaulting untyped conditions to S
+func (c FilterCondition) Type() string {
+	if c.ValueType == "" {
+		return ValueTypeString
+	}
+	return c.ValueType
+}
+
+func (c FilterCondition) String() string {
+	if c.IsGroup() {
+		var parts []string
+		for _, child := range c.Children {
+			parts = append(parts, child.String())
+		}
+		s := "(" + strings.Join(parts, " "+strings.ToUpper(c.Group)+" ") + ")"
+		if c.Negate {
+			return "NOT " + s
+		}
+		return s
+	}
+
+	var s string
+	switch {
+	case !takesValue(c.Operator):
+		s = fmt.Sprintf("%s %s", c.Column, c.Operator)
+	case c.Operator == "type":
+		s = fmt.Sprintf("%s type %s", c.Column, c.Value)
+	case IsSizeOperator(c.Operator):
+		s = fmt.Sprintf("size(%s) %s %s", c.Column, SizeComparator(c.Operator), c.Value)
+	case c.Type() == ValueTypeNull:
+		s = fmt.Sprintf("%s %s null", c.Column, c.Operator)
+	case c.Operator == "between":
+		s = fmt.Sprintf("%s between %s (%s)", c.Column, strings.Join(c.Values, " and "), strings.ToLower(c.Type()))
+	case c.Operator == "in":
+		s = fmt.Sprintf("%s in (%s) (%s)", c.Column, strings.Join(c.Values, ", "), strings.ToLower(c.Type()))
+	default:
+		s = fmt.Sprintf("%s %s %s (%s)", c.Column, c.Operator, c.Value, strings.ToLower(c.Type()))
+	}
+
+	if c.Negate {
+		s = "NOT " + s
+	}
+	if IsClientSide(c.Operator) {
+		s += " [client-side]"
+	}
+	return s
+}
+
+// addCondition appends a condition, either ANDed at the top level or as an
+// OR alternative to the last condition
+func addCondition(conditions []FilterCondition, cond FilterCondition, or bool) []FilterCondition {
+	if !or || len(conditions) == 0 {
+		return append(conditions, cond)
+	}
+
+	last := conditions[len(conditions)-1]
+	if last.Group == GroupOr && !last.Negate {
+		last.Children = append(last.Children, cond)
+	} else {
+		last = FilterCondition{Group: GroupOr, Children: []FilterCondition{last, cond}}
+	}
+	conditions[len(conditions)-1] = last
+	return conditions
+}
+
+// parseValueType normalises user input into one of the ValueType constants
+func parseValueType(s string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "", "AUTO":
+		return ValueTypeAuto, nil
+	case "S", "STRING":
+		return ValueTypeString, nil
+	case "N", "NUMBER":
+		return ValueTypeNumber, nil
+	case "BOOL", "BOOLEAN":
+		return ValueTypeBool, nil
+	case "NULL":
+		return ValueTypeNull, nil
+	}
+	return "", fmt.Errorf("unknown value type %q", s)
+}
+
+// validateValue checks that a value can be sent as the given type
+func validateValue(valueType string, value string) error {
+	switch valueType {
+	case ValueTypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+	case ValueTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+	}
+	return nil
 }
 
 type ItemFilterModel struct {
 	columnInput   textinput.Model
 	operatorInput textinput.Model
 	valueInput    textinput.Model
+	typeInput     textinput.Model
 	focusIndex    int
+	negate        bool // Next condition is added as NOT
+	err           error
 	Conditions    []FilterCondition
+
+	// Text mode edits the whole filter as one expression
+	textMode    bool
+	queryInput  textinput.Model
+	columns     []string // Known column names for completion
+	completions []string
 }
 
 func NewItemFilterModel() ItemFilterModel {
@@ -30,41 +166,61 @@ func NewItemFilterModel() ItemFilterModel {
 	colInput.Width = 30
 
 	opInput := textinput.New()
-	opInput.Placeholder = "Operator (==, !=, contains)"
+	opInput.Placeholder = "Operator (==, <, between, in...)"
 	opInput.Width = 20
 
 	valInput := textinput.New()
-	valInput.Placeholder = "Value (e.g., consumer)"
-	valInput.Width = 30
+	valInput.Placeholder = "Value (e.g., consumer; low, high for between)"
+	valInput.Width = 50
+
+	typeInput := textinput.New()
+	typeInput.Placeholder = "Type (S, N, BOOL, NULL; empty infers)"
+	typeInput.Width = 30
+
+	queryInput := textinput.New()
+	queryInput.Placeholder = `status = "FAILED" and attempts > 3 and not exists(deletedAt)`
+	queryInput.Focus()
+	queryInput.Width = 100
 
 	return ItemFilterModel{
 		columnInput:   colInput,
 		operatorInput: opInput,
 		valueInput:    valInput,
+		typeInput:     typeInput,
 		focusIndex:    0,
+		textMode:      true,
+		queryInput:    queryInput,
 	}
 }
 
 func (m ItemFilterModel) Update(msg tea.Msg) (ItemFilterModel, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "ctrl+e" {
+		return m.toggleMode(), nil
+	}
+	if m.textMode {
+		return m.updateText(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "tab", "shift+tab":
 			// Cycle through inputs
 			if msg.String() == "tab" {
-				m.focusIndex = (m.focusIndex + 1) % 3
+				m.focusIndex = (m.focusIndex + 1) % 4
 			} else {
 				m.focusIndex--
 				if m.focusIndex < 0 {
-					m.focusIndex = 2
+					m.focusIndex = 3
 				}
 			}
 
 			m.columnInput.Blur()
 			m.operatorInput.Blur()
 			m.valueInput.Blur()
+			m.typeInput.Blur()
 
 			switch m.focusIndex {
 			case 0:
@@ -73,29 +229,77 @@ func (m ItemFilterModel) Update(msg tea.Msg) (ItemFilterModel, tea.Cmd) {
 				m.operatorInput.Focus()
 			case 2:
 				m.valueInput.Focus()
+			case 3:
+				m.typeInput.Focus()
 			}
 
 			return m, nil
 
-		case "enter":
-			// Add condition
+		case "ctrl+t":
+			// Toggle NOT for the next condition
+			m.negate = !m.negate
+			return m, nil
+
+		case "enter", "ctrl+o":
+			// Add condition (ctrl+o adds it as an OR alternative to the last one)
+			valueType, err := parseValueType(m.typeInput.Value())
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+
+			operator := strings.TrimSpace(m.operatorInput.Value())
+
+			// NULL comparisons and existence checks don't need a value
+			hasValue := m.valueInput.Value() != "" ||
+				valueType == ValueTypeNull ||
+				(operator != "" && !takesValue(operator))
+
 			if m.columnInput.Value() != "" &&
-				m.operatorInput.Value() != "" &&
-				m.valueInput.Value() != "" {
-				m.Conditions = append(m.Conditions, FilterCondition{
-					Column:   m.columnInput.Value(),
-					Operator: m.operatorInput.Value(),
-					Value:    m.valueInput.Value(),
-				})
+				operator != "" &&
+				hasValue {
+				cond := FilterCondition{
+					Column:    m.columnInput.Value(),
+					Operator:  operator,
+					ValueType: valueType,
+					Negate:    m.negate,
+				}
+				switch operators[operator].operand {
+				case operandRange, operandList:
+					cond.Values = splitValues(m.valueInput.Value())
+				case operandSingle:
+					cond.Value = m.valueInput.Value()
+				}
+
+				if err := cond.Validate(); err != nil {
+					m.err = err
+					return m, nil
+				}
+
+				or := msg.String() == "ctrl+o"
+				if or && IsClientSide(operator) {
+					m.err = fmt.Errorf("%s is applied client-side and can't be used in an OR group", operator)
+					return m, nil
+				}
+				if or && len(m.Conditions) > 0 && m.Conditions[len(m.Conditions)-1].hasClientSide() {
+					m.err = fmt.Errorf("the last condition is applied client-side and can't be used in an OR group")
+					return m, nil
+				}
+
+				m.Conditions = addCondition(m.Conditions, cond, or)
+				m.negate = false
 
 				// Clear inputs
+				m.err = nil
 				m.columnInput.SetValue("")
 				m.operatorInput.SetValue("")
 				m.valueInput.SetValue("")
+				m.typeInput.SetValue("")
 				m.focusIndex = 0
 				m.columnInput.Focus()
 				m.operatorInput.Blur()
 				m.valueInput.Blur()
+				m.typeInput.Blur()
 			}
 			return m, nil
 
@@ -104,6 +308,7 @@ func (m ItemFilterModel) Update(msg tea.Msg) (ItemFilterModel, tea.Cmd) {
 			if m.columnInput.Value() == "" &&
 				m.operatorInput.Value() == "" &&
 				m.valueInput.Value() == "" &&
+				m.typeInput.Value() == "" &&
 				len(m.Conditions) > 0 {
 				m.Conditions = m.Conditions[:len(m.Conditions)-1]
 				return m, nil
@@ -119,6 +324,8 @@ func (m ItemFilterModel) Update(msg tea.Msg) (ItemFilterModel, tea.Cmd) {
 		m.operatorInput, cmd = m.operatorInput.Update(msg)
 	case 2:
 		m.valueInput, cmd = m.valueInput.Update(msg)
+	case 3:
+		m.typeInput, cmd = m.typeInput.Update(msg)
 	}
 
 	return m, cmd
@@ -144,29 +351,52 @@ func (m ItemFilterModel) View() string {
 			b.WriteString(fmt.Sprintf(
 				"  %d. %s\n",
 				i+1,
-				filterStyle.Render(
-					fmt.Sprintf("%s %s %s", cond.Column, cond.Operator, cond.Value),
-				),
+				filterStyle.Render(cond.String()),
 			))
 		}
 		b.WriteString("\n")
 	}
 
+	if m.textMode {
+		b.WriteString(m.viewText())
+		return b.String()
+	}
+
 	// Input form
 	b.WriteString("Add Filter Condition:\n\n")
+	if m.negate {
+		negateStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+		b.WriteString(negateStyle.Render("NOT") + "\n")
+	}
 	b.WriteString(fmt.Sprintf("Column:   %s\n", m.columnInput.View()))
 	b.WriteString(fmt.Sprintf("Operator: %s\n", m.operatorInput.View()))
 	b.WriteString(fmt.Sprintf("Value:    %s\n", m.valueInput.View()))
+	b.WriteString(fmt.Sprintf("Type:     %s\n", m.typeInput.View()))
+
+	if m.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render(m.err.Error()))
+		b.WriteString("\n")
+	}
 
 	b.WriteString("\n")
 	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 	b.WriteString(
-		helpStyle.Render("Operators: == (equals), != (not equals), contains, startswith, endswith"),
+		helpStyle.Render("Operators: == != < <= > >= • between (low, high) • in (a, b, c) • contains • startswith"),
+	)
+	b.WriteString("\n")
+	b.WriteString(
+		helpStyle.Render("           exists • notexists • type (S, N, BOOL, L, M...) • size== size< size> ..."),
+	)
+	b.WriteString("\n")
+	b.WriteString(
+		helpStyle.Render("           endswith (applied client-side to loaded items)"),
 	)
 	b.WriteString("\n")
 	b.WriteString(
 		helpStyle.Render(
-			"Tab: Next field • Enter: Add condition • Backspace: Remove last • Ctrl+S: Apply • Esc: Cancel",
+			"Tab: Next field • Enter: Add (AND) • Ctrl+O: Add (OR last) • Ctrl+T: Toggle NOT • Backspace: Remove last • Ctrl+E: Text mode • Ctrl+S: Apply • Esc: Cancel",
 		),
 	)
 
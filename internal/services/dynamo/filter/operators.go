@@ -0,0 +1,163 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// operandKind describes what an operator expects on its right-hand side
+type operandKind int
+
+const (
+	operandSingle operandKind = iota
+	operandNone               // exists, notexists
+	operandRange              // between: low and high
+	operandList               // in: one or more values
+)
+
+type operatorInfo struct {
+	operand    operandKind
+	clientSide bool // Evaluated locally after the items are loaded
+}
+
+var operators = map[string]operatorInfo{
+	"==":         {operand: operandSingle},
+	"!=":         {operand: operandSingle},
+	"<":          {operand: operandSingle},
+	"<=":         {operand: operandSingle},
+	">":          {operand: operandSingle},
+	">=":         {operand: operandSingle},
+	"between":    {operand: operandRange},
+	"in":         {operand: operandList},
+	"contains":   {operand: operandSingle},
+	"startswith": {operand: operandSingle},
+	"endswith":   {operand: operandSingle, clientSide: true},
+	"exists":     {operand: operandNone},
+	"notexists":  {operand: operandNone},
+	"type":       {operand: operandSingle},
+	"size==":     {operand: operandSingle},
+	"size!=":     {operand: operandSingle},
+	"size<":      {operand: operandSingle},
+	"size<=":     {operand: operandSingle},
+	"size>":      {operand: operandSingle},
+	"size>=":     {operand: operandSingle},
+}
+
+// DynamoDB attribute type codes accepted by the type operator
+var attributeTypes = []string{"S", "SS", "N", "NS", "B", "BS", "BOOL", "NULL", "L", "M"}
+
+// IsClientSide reports whether an operator can't be expressed as a DynamoDB
+// FilterExpression and is applied to loaded items instead
+func IsClientSide(operator string) bool {
+	return operators[operator].clientSide
+}
+
+// IsSizeOperator reports whether an operator compares size() of the attribute
+func IsSizeOperator(operator string) bool {
+	return strings.HasPrefix(operator, "size")
+}
+
+// SizeComparator returns the comparison part of a size operator
+func SizeComparator(operator string) string {
+	return strings.TrimPrefix(operator, "size")
+}
+
+// takesValue reports whether an operator needs a value on its right-hand side
+func takesValue(operator string) bool {
+	return operators[operator].operand != operandNone
+}
+
+// Operands returns the values an operator compares against
+func (c FilterCondition) Operands() []string {
+	if c.IsGroup() {
+		return nil
+	}
+	switch operators[c.Operator].operand {
+	case operandNone:
+		return nil
+	case operandRange, operandList:
+		return c.Values
+	default:
+		return []string{c.Value}
+	}
+}
+
+// hasClientSide reports whether the condition or any of its children is client-side
+func (c FilterCondition) hasClientSide() bool {
+	if !c.IsGroup() {
+		return IsClientSide(c.Operator)
+	}
+	for _, child := range c.Children {
+		if child.hasClientSide() {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate rejects unknown operators and operands that don't fit them
+func (c FilterCondition) Validate() error {
+	if c.IsGroup() {
+		if c.Group != GroupAnd && c.Group != GroupOr {
+			return fmt.Errorf("unknown group %q", c.Group)
+		}
+		if len(c.Children) == 0 {
+			return fmt.Errorf("empty %s group", c.Group)
+		}
+		for _, child := range c.Children {
+			// Client-side conditions only narrow the top-level AND
+			if child.hasClientSide() {
+				return fmt.Errorf("%s can't be used inside a group", child.String())
+			}
+			if err := child.Validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	info, ok := operators[c.Operator]
+	if !ok {
+		return fmt.Errorf("unknown operator %q", c.Operator)
+	}
+
+	switch info.operand {
+	case operandRange:
+		if len(c.Values) != 2 {
+			return fmt.Errorf("between needs two values: low, high")
+		}
+	case operandList:
+		if len(c.Values) == 0 {
+			return fmt.Errorf("in needs at least one value")
+		}
+	}
+
+	if c.Operator == "type" {
+		for _, t := range attributeTypes {
+			if t == strings.ToUpper(c.Value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown attribute type %q (use %s)", c.Value, strings.Join(attributeTypes, ", "))
+	}
+
+	if IsSizeOperator(c.Operator) {
+		return validateValue(ValueTypeNumber, c.Value)
+	}
+
+	for _, v := range c.Operands() {
+		if err := validateValue(c.Type(), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitValues splits comma separated operands for between and in
+func splitValues(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		values = append(values, strings.TrimSpace(v))
+	}
+	return values
+}
@@ -0,0 +1,635 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The filter language is a single line boolean expression, e.g.
+//
+//	status = "FAILED" and attempts > 3 and not exists(deletedAt)
+//	(type = consumer or type = producer) and payload.customer.id startswith "c-"
+//	size(events) >= 2 and createdAt between "2024-01-01" and "2024-02-01"
+//
+// Quoted values are strings, numbers are N, true/false are BOOL, null is NULL
+// and bare words are inferred from the column's sampled items. Backticks make
+// anything but a backtick a bare word, e.g. `two words` or `1.5`.
+
+// ParseError points at the position in the input that couldn't be parsed
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("col %d: %s", e.Pos+1, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// keyword reports whether an identifier token is the given keyword
+func (t token) keyword(kw string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.text, kw)
+}
+
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '`' || r == '#' || r == '$' || r == '@'
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r) || r == '-' || r == '.' || r == '[' || r == ']' || r == ':'
+}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	i := 0
+
+	for i < len(runes) {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+
+		case r == '"' || r == '\'':
+			// Escapes follow Go's rules, so values written by Format with strconv.Quote read back unchanged
+			start := i
+			rest := string(runes[i+1:])
+			var b strings.Builder
+			for {
+				if rest == "" {
+					return nil, &ParseError{Pos: start, Msg: "unterminated string"}
+				}
+				if rest[0] == byte(r) {
+					break
+				}
+				value, multibyte, tail, err := strconv.UnquoteChar(rest, byte(r))
+				if err != nil {
+					return nil, &ParseError{Pos: start, Msg: "invalid escape in string"}
+				}
+				if value < utf8.RuneSelf || !multibyte {
+					b.WriteByte(byte(value))
+				} else {
+					b.WriteRune(value)
+				}
+				i += utf8.RuneCountInString(rest[:len(rest)-len(tail)])
+				rest = tail
+			}
+			i += 2
+			tokens = append(tokens, token{kind: tokenString, text: b.String(), pos: start})
+
+		case strings.ContainsRune("=!<>", r):
+			start := i
+			i++
+			if i < len(runes) && (runes[i] == '=' || (r == '<' && runes[i] == '>')) {
+				i++
+			}
+			op := string(runes[start:i])
+			if op == "!" {
+				return nil, &ParseError{Pos: start, Msg: "expected != "}
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: start})
+
+		case unicode.IsDigit(r) || ((r == '-' || r == '+') && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE", runes[i]) ||
+				((runes[i] == '-' || runes[i] == '+') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			text := string(runes[start:i])
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return nil, &ParseError{Pos: start, Msg: fmt.Sprintf("invalid number %q", text)}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, pos: start})
+
+		case isIdentStart(r):
+			start := i
+			for i < len(runes) && isIdentPart(runes[i]) {
+				// Backticks quote a path segment that may contain anything but a backtick
+				if runes[i] == '`' {
+					i++
+					for i < len(runes) && runes[i] != '`' {
+						i++
+					}
+					if i >= len(runes) {
+						return nil, &ParseError{Pos: start, Msg: "unterminated `"}
+					}
+				}
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+
+		default:
+			return nil, &ParseError{Pos: i, Msg: fmt.Sprintf("unexpected %q", r)}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &ParseError{Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.errorf(t, "expected %s, found %s", what, describe(t))
+	}
+	return t, nil
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of input"
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// Parse parses a filter expression into conditions that are combined with AND
+func Parse(input string) ([]FilterCondition, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "expected and/or, found %s", describe(t))
+	}
+
+	// A top-level AND becomes the condition list itself
+	var conditions []FilterCondition
+	if root.Group == GroupAnd && !root.Negate {
+		conditions = root.Children
+	} else {
+		conditions = []FilterCondition{root}
+	}
+
+	for _, c := range conditions {
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return conditions, nil
+}
+
+func (p *parser) parseOr() (FilterCondition, error) {
+	return p.parseGroup(GroupOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (FilterCondition, error) {
+	return p.parseGroup(GroupAnd, p.parseUnary)
+}
+
+// parseGroup parses operands joined by the group keyword, flattening nested groups of the same kind
+func (p *parser) parseGroup(group string, operand func() (FilterCondition, error)) (FilterCondition, error) {
+	first, err := operand()
+	if err != nil {
+		return first, err
+	}
+	if !p.peek().keyword(group) {
+		return first, nil
+	}
+
+	node := FilterCondition{Group: group}
+	add := func(c FilterCondition) {
+		if c.Group == group && !c.Negate {
+			node.Children = append(node.Children, c.Children...)
+		} else {
+			node.Children = append(node.Children, c)
+		}
+	}
+	add(first)
+
+	for p.peek().keyword(group) {
+		p.next()
+		c, err := operand()
+		if err != nil {
+			return c, err
+		}
+		add(c)
+	}
+	return node, nil
+}
+
+func (p *parser) parseUnary() (FilterCondition, error) {
+	if p.peek().keyword("not") {
+		p.next()
+		c, err := p.parseUnary()
+		if err != nil {
+			return c, err
+		}
+		c.Negate = !c.Negate
+		return c, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (FilterCondition, error) {
+	t := p.peek()
+
+	if t.kind == tokenLParen {
+		p.next()
+		c, err := p.parseOr()
+		if err != nil {
+			return c, err
+		}
+		if _, err := p.expect(tokenRParen, ")"); err != nil {
+			return c, err
+		}
+		return c, nil
+	}
+
+	if t.kind != tokenIdent {
+		return FilterCondition{}, p.errorf(t, "expected a column name, found %s", describe(t))
+	}
+
+	// Function call forms
+	if p.tokens[p.pos+1].kind == tokenLParen {
+		if c, ok, err := p.parseFunction(); ok || err != nil {
+			return c, err
+		}
+	}
+
+	column := columnName(p.next())
+	op := p.next()
+
+	switch {
+	case op.kind == tokenOperator:
+		value, valueType, err := p.parseValue()
+		if err != nil {
+			return FilterCondition{}, err
+		}
+		return FilterCondition{
+			Column:    column,
+			Operator:  normaliseComparison(op.text),
+			Value:     value,
+			ValueType: valueType,
+		}, nil
+
+	case op.keyword("contains"), op.keyword("startswith"), op.keyword("begins_with"), op.keyword("endswith"):
+		value, valueType, err := p.parseValue()
+		if err != nil {
+			return FilterCondition{}, err
+		}
+		return FilterCondition{
+			Column:    column,
+			Operator:  normaliseFunction(op.text),
+			Value:     value,
+			ValueType: valueType,
+		}, nil
+
+	case op.keyword("between"):
+		low, lowType, err := p.parseValue()
+		if err != nil {
+			return FilterCondition{}, err
+		}
+		if t := p.next(); !t.keyword("and") {
+			return FilterCondition{}, p.errorf(t, "expected and in between, found %s", describe(t))
+		}
+		high, highType, err := p.parseValue()
+		if err != nil {
+			return FilterCondition{}, err
+		}
+		valueType, err := mergeValueTypes(op, lowType, highType)
+		if err != nil {
+			return FilterCondition{}, err
+		}
+		return FilterCondition{
+			Column:    column,
+			Operator:  "between",
+			Values:    []string{low, high},
+			ValueType: valueType,
+		}, nil
+
+	case op.keyword("in"):
+		if _, err := p.expect(tokenLParen, "( after in"); err != nil {
+			return FilterCondition{}, err
+		}
+		c := FilterCondition{Column: column, Operator: "in"}
+		var valueTypes []string
+		for {
+			value, valueType, err := p.parseValue()
+			if err != nil {
+				return c, err
+			}
+			c.Values = append(c.Values, value)
+			valueTypes = append(valueTypes, valueType)
+
+			t := p.next()
+			if t.kind == tokenRParen {
+				break
+			}
+			if t.kind != tokenComma {
+				return c, p.errorf(t, "expected , or ), found %s", describe(t))
+			}
+		}
+		valueType, err := mergeValueTypes(op, valueTypes...)
+		if err != nil {
+			return c, err
+		}
+		c.ValueType = valueType
+		return c, nil
+	}
+
+	return FilterCondition{}, p.errorf(op, "expected an operator after %s, found %s", column, describe(op))
+}
+
+// parseFunction parses exists(x), type(x, S), size(x) > 3 and contains(x, v) style calls.
+// ok is false if the identifier isn't a known function.
+func (p *parser) parseFunction() (FilterCondition, bool, error) {
+	name := p.peek()
+	fn := strings.ToLower(name.text)
+
+	switch fn {
+	case "exists", "attribute_exists", "notexists", "not_exists", "attribute_not_exists",
+		"type", "attribute_type", "size", "contains", "startswith", "begins_with", "endswith":
+	default:
+		return FilterCondition{}, false, nil
+	}
+
+	p.next()
+	p.next() // (
+
+	column, err := p.expect(tokenIdent, "a column name")
+	if err != nil {
+		return FilterCondition{}, true, err
+	}
+
+	c := FilterCondition{Column: columnName(column)}
+
+	switch fn {
+	case "exists", "attribute_exists":
+		c.Operator = "exists"
+	case "notexists", "not_exists", "attribute_not_exists":
+		c.Operator = "notexists"
+	case "size":
+	default:
+		if _, err := p.expect(tokenComma, ","); err != nil {
+			return c, true, err
+		}
+		value, valueType, err := p.parseValue()
+		if err != nil {
+			return c, true, err
+		}
+		c.Value = value
+		c.ValueType = valueType
+		c.Operator = normaliseFunction(fn)
+	}
+
+	if _, err := p.expect(tokenRParen, ")"); err != nil {
+		return c, true, err
+	}
+
+	if fn == "size" {
+		op, err := p.expect(tokenOperator, "a comparison after size()")
+		if err != nil {
+			return c, true, err
+		}
+		n, err := p.expect(tokenNumber, "a number")
+		if err != nil {
+			return c, true, err
+		}
+		c.Operator = "size" + normaliseComparison(op.text)
+		c.Value = n.text
+		c.ValueType = ValueTypeNumber
+	}
+
+	return c, true, nil
+}
+
+// columnName returns a column token's name. A backquoted name that isn't a
+// path loses its backticks, the way Format writes it.
+func columnName(t token) string {
+	if inner, ok := backquoted(t.text); ok && !strings.ContainsAny(inner, ".[") {
+		return inner
+	}
+	return t.text
+}
+
+func (p *parser) parseValue() (string, string, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokenString:
+		return t.text, ValueTypeString, nil
+	case t.kind == tokenNumber:
+		return t.text, ValueTypeNumber, nil
+	case t.keyword("true"), t.keyword("false"):
+		return strings.ToLower(t.text), ValueTypeBool, nil
+	case t.keyword("null"):
+		return "", ValueTypeNull, nil
+	case t.kind == tokenIdent:
+		// Bare words take the type of the column
+		if inner, ok := backquoted(t.text); ok {
+			return inner, ValueTypeAuto, nil
+		}
+		return t.text, ValueTypeAuto, nil
+	}
+	return "", "", p.errorf(t, "expected a value, found %s", describe(t))
+}
+
+// mergeValueTypes picks the single value type shared by the operands of between and in
+func mergeValueTypes(op token, valueTypes ...string) (string, error) {
+	merged := ValueTypeAuto
+	for _, t := range valueTypes {
+		switch {
+		case t == ValueTypeAuto:
+		case merged == ValueTypeAuto:
+			merged = t
+		case merged != t:
+			return "", &ParseError{Pos: op.pos, Msg: fmt.Sprintf("%s values must all have the same type", op.text)}
+		}
+	}
+	return merged, nil
+}
+
+func normaliseComparison(op string) string {
+	switch op {
+	case "=":
+		return "=="
+	case "<>":
+		return "!="
+	}
+	return op
+}
+
+func normaliseFunction(fn string) string {
+	switch strings.ToLower(fn) {
+	case "begins_with", "startswith":
+		return "startswith"
+	case "attribute_type":
+		return "type"
+	}
+	return strings.ToLower(fn)
+}
+
+// Format renders conditions in the filter language, so they can be edited as text
+func Format(conditions []FilterCondition) string {
+	var parts []string
+	for _, c := range conditions {
+		parts = append(parts, formatCondition(c))
+	}
+	return strings.Join(parts, " and ")
+}
+
+func formatCondition(c FilterCondition) string {
+	var s string
+
+	if c.IsGroup() {
+		var parts []string
+		for _, child := range c.Children {
+			parts = append(parts, formatCondition(child))
+		}
+		s = "(" + strings.Join(parts, " "+c.Group+" ") + ")"
+	} else {
+		s = formatLeaf(c)
+	}
+
+	if c.Negate {
+		return "not " + s
+	}
+	return s
+}
+
+func formatLeaf(c FilterCondition) string {
+	column := formatColumn(c.Column)
+	switch {
+	case c.Operator == "exists":
+		return fmt.Sprintf("exists(%s)", column)
+	case c.Operator == "notexists":
+		return fmt.Sprintf("not_exists(%s)", column)
+	case c.Operator == "type":
+		return fmt.Sprintf("type(%s, %s)", column, strconv.Quote(c.Value))
+	case IsSizeOperator(c.Operator):
+		return fmt.Sprintf("size(%s) %s %s", column, SizeComparator(c.Operator), c.Value)
+	case c.Operator == "between":
+		return fmt.Sprintf("%s between %s and %s", column, formatValue(c, c.Values[0]), formatValue(c, c.Values[1]))
+	case c.Operator == "in":
+		var values []string
+		for _, v := range c.Values {
+			values = append(values, formatValue(c, v))
+		}
+		return fmt.Sprintf("%s in (%s)", column, strings.Join(values, ", "))
+	}
+
+	op := c.Operator
+	if op == "==" {
+		op = "="
+	}
+	return fmt.Sprintf("%s %s %s", column, op, formatValue(c, c.Value))
+}
+
+// formatColumn backquotes column names that can't be written bare, like
+// keywords or names with spaces. Paths are written as they are, since
+// backquoting a whole path would turn it into a single attribute name.
+func formatColumn(column string) string {
+	if isBareWord(column) || strings.ContainsAny(column, "`.[") {
+		return column
+	}
+	return "`" + column + "`"
+}
+
+func formatValue(c FilterCondition, value string) string {
+	switch c.Type() {
+	case ValueTypeNumber, ValueTypeBool:
+		return value
+	case ValueTypeNull:
+		return "null"
+	case ValueTypeAuto:
+		if isBareWord(value) {
+			return value
+		}
+		if !strings.Contains(value, "`") {
+			return "`" + value + "`"
+		}
+	}
+	return strconv.Quote(value)
+}
+
+// backquoted returns the text of a bare word written entirely in backticks
+func backquoted(s string) (string, bool) {
+	if len(s) < 2 || s[0] != '`' || s[len(s)-1] != '`' || strings.Contains(s[1:len(s)-1], "`") {
+		return "", false
+	}
+	return s[1 : len(s)-1], true
+}
+
+// isBareWord reports whether a value can be written unquoted without changing meaning
+func isBareWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "and", "or", "not", "true", "false", "null", "between", "in":
+		return false
+	}
+	for i, r := range s {
+		if (i == 0 && !isIdentStart(r)) || !isIdentPart(r) || r == '`' {
+			return false
+		}
+	}
+	return true
+}
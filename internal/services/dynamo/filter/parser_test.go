@@ -0,0 +1,289 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func leaf(column, operator, value, valueType string) FilterCondition {
+	return FilterCondition{Column: column, Operator: operator, Value: value, ValueType: valueType}
+}
+
+func group(name string, children ...FilterCondition) FilterCondition {
+	return FilterCondition{Group: name, Children: children}
+}
+
+func negate(c FilterCondition) FilterCondition {
+	c.Negate = !c.Negate
+	return c
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []FilterCondition
+	}{
+		{
+			name:  "empty",
+			input: "  ",
+			want:  nil,
+		},
+		{
+			name:  "top-level and becomes the list",
+			input: `a = 1 and b = "x"`,
+			want: []FilterCondition{
+				leaf("a", "==", "1", ValueTypeNumber),
+				leaf("b", "==", "x", ValueTypeString),
+			},
+		},
+		{
+			name:  "and binds tighter than or",
+			input: "a = 1 or b = 2 and c = 3",
+			want: []FilterCondition{
+				group(GroupOr,
+					leaf("a", "==", "1", ValueTypeNumber),
+					group(GroupAnd,
+						leaf("b", "==", "2", ValueTypeNumber),
+						leaf("c", "==", "3", ValueTypeNumber),
+					),
+				),
+			},
+		},
+		{
+			name:  "parentheses override precedence",
+			input: "(a = 1 or b = 2) and c = 3",
+			want: []FilterCondition{
+				group(GroupOr,
+					leaf("a", "==", "1", ValueTypeNumber),
+					leaf("b", "==", "2", ValueTypeNumber),
+				),
+				leaf("c", "==", "3", ValueTypeNumber),
+			},
+		},
+		{
+			name:  "nested groups of the same kind are flattened",
+			input: "a = 1 or (b = 2 or c = 3)",
+			want: []FilterCondition{
+				group(GroupOr,
+					leaf("a", "==", "1", ValueTypeNumber),
+					leaf("b", "==", "2", ValueTypeNumber),
+					leaf("c", "==", "3", ValueTypeNumber),
+				),
+			},
+		},
+		{
+			name:  "not binds tighter than and",
+			input: "not a = 1 and b = 2",
+			want: []FilterCondition{
+				negate(leaf("a", "==", "1", ValueTypeNumber)),
+				leaf("b", "==", "2", ValueTypeNumber),
+			},
+		},
+		{
+			name:  "not of a group",
+			input: "not (a = 1 or b = 2)",
+			want: []FilterCondition{
+				negate(group(GroupOr,
+					leaf("a", "==", "1", ValueTypeNumber),
+					leaf("b", "==", "2", ValueTypeNumber),
+				)),
+			},
+		},
+		{
+			name:  "negated groups are not flattened",
+			input: "a = 1 or not (b = 2 or c = 3)",
+			want: []FilterCondition{
+				group(GroupOr,
+					leaf("a", "==", "1", ValueTypeNumber),
+					negate(group(GroupOr,
+						leaf("b", "==", "2", ValueTypeNumber),
+						leaf("c", "==", "3", ValueTypeNumber),
+					)),
+				),
+			},
+		},
+		{
+			name:  "double not cancels",
+			input: "not not a = 1",
+			want:  []FilterCondition{leaf("a", "==", "1", ValueTypeNumber)},
+		},
+		{
+			name:  "value types",
+			input: "a <> true and b >= -1.5e3 and c = null and d = word",
+			want: []FilterCondition{
+				leaf("a", "!=", "true", ValueTypeBool),
+				leaf("b", ">=", "-1.5e3", ValueTypeNumber),
+				leaf("c", "==", "", ValueTypeNull),
+				leaf("d", "==", "word", ValueTypeAuto),
+			},
+		},
+		{
+			name:  "between takes the type of its typed bound",
+			input: `a between low and "m"`,
+			want: []FilterCondition{
+				{Column: "a", Operator: "between", Values: []string{"low", "m"}, ValueType: ValueTypeString},
+			},
+		},
+		{
+			name:  "between of bare words stays auto",
+			input: "a between x and y",
+			want: []FilterCondition{
+				{Column: "a", Operator: "between", Values: []string{"x", "y"}, ValueType: ValueTypeAuto},
+			},
+		},
+		{
+			name:  "in merges its value types",
+			input: `a in ("x", y, "z")`,
+			want: []FilterCondition{
+				{Column: "a", Operator: "in", Values: []string{"x", "y", "z"}, ValueType: ValueTypeString},
+			},
+		},
+		{
+			name:  "functions",
+			input: `exists(a) and not_exists(b) and size(c) > 2 and begins_with(d, "p") and type(e, "SS")`,
+			want: []FilterCondition{
+				{Column: "a", Operator: "exists"},
+				{Column: "b", Operator: "notexists"},
+				leaf("c", "size>", "2", ValueTypeNumber),
+				leaf("d", "startswith", "p", ValueTypeString),
+				leaf("e", "type", "SS", ValueTypeString),
+			},
+		},
+		{
+			name:  "backtick path segments",
+			input: "`odd name`.child[0] = 1 and a.`b.c` contains x",
+			want: []FilterCondition{
+				leaf("`odd name`.child[0]", "==", "1", ValueTypeNumber),
+				leaf("a.`b.c`", "contains", "x", ValueTypeAuto),
+			},
+		},
+		{
+			name:  "backquoted bare words",
+			input: "a = `two words` and b = `1.5` and c in (`and`, ``)",
+			want: []FilterCondition{
+				leaf("a", "==", "two words", ValueTypeAuto),
+				leaf("b", "==", "1.5", ValueTypeAuto),
+				{Column: "c", Operator: "in", Values: []string{"and", ""}, ValueType: ValueTypeAuto},
+			},
+		},
+		{
+			name:  "string escapes",
+			input: `a = "tab\there \"quoted\" \\ é\x41" and b = 'it\'s'`,
+			want: []FilterCondition{
+				leaf("a", "==", "tab\there \"quoted\" \\ éA", ValueTypeString),
+				leaf("b", "==", "it's", ValueTypeString),
+			},
+		},
+		{
+			name:  "keywords are case insensitive",
+			input: "A = 1 AND NOT B Between 1 And 2",
+			want: []FilterCondition{
+				leaf("A", "==", "1", ValueTypeNumber),
+				negate(FilterCondition{Column: "B", Operator: "between", Values: []string{"1", "2"}, ValueType: ValueTypeNumber}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q)\n got  %+v\n want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{input: "a between 1 and \"x\"", pos: 2},
+		{input: "a in (1, \"x\")", pos: 2},
+		{input: "a = \"open", pos: 4},
+		{input: "a = \"bad \\q escape\"", pos: 4},
+		{input: "`open = 1", pos: 0},
+		{input: "a = 1 b = 2", pos: 6},
+		{input: "(a = 1", pos: 6},
+		{input: "a ! 1", pos: 2},
+		{input: "a = 1.2.3", pos: 4},
+		{input: "= 1", pos: 0},
+		{input: "a in 1", pos: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse(%q) = %v, want a ParseError", tt.input, err)
+			}
+			if parseErr.Pos != tt.pos {
+				t.Errorf("Parse(%q) error at %d, want %d: %v", tt.input, parseErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	tests := [][]FilterCondition{
+		{leaf("a", "==", "1", ValueTypeNumber)},
+		{leaf("a", "!=", "false", ValueTypeBool), leaf("b", "==", "", ValueTypeNull)},
+		{leaf("a", "==", "word", ValueTypeAuto), leaf("b", "<", "two words", ValueTypeAuto)},
+		{leaf("a", "==", "and", ValueTypeAuto), leaf("b", "==", "", ValueTypeAuto), leaf("c", "<", "1.5", ValueTypeAuto)},
+		{leaf("a", "==", "line\nbreak\ttab \"quote\" back\\slash", ValueTypeString)},
+		{leaf("a", "==", "bell\a nul\x00 unicode é 🚀 zero-width​", ValueTypeString)},
+		{leaf("a", "contains", "it's", ValueTypeString), leaf("b", "endswith", ".json", ValueTypeString)},
+		{leaf("`odd name`.child[0]", ">=", "-2.5", ValueTypeNumber)},
+		{leaf("`a.b`", "==", "1", ValueTypeNumber), leaf("a.b[0]", "==", "2", ValueTypeNumber)},
+		{
+			leaf("two words", "==", "x", ValueTypeString),
+			leaf("and", ">", "1", ValueTypeNumber),
+			leaf("between", "<", "b", ValueTypeAuto),
+			leaf("1st", "type", "S", ValueTypeString),
+			leaf("size", "size>", "1", ValueTypeNumber),
+			{Column: "not", Operator: "exists"},
+			{Column: "a b", Operator: "between", Values: []string{"1", "2"}, ValueType: ValueTypeNumber},
+			{Column: "in", Operator: "in", Values: []string{"x"}, ValueType: ValueTypeString},
+		},
+		{{Column: "a", Operator: "between", Values: []string{"1", "9"}, ValueType: ValueTypeNumber}},
+		{{Column: "a", Operator: "in", Values: []string{"x", "y z", "\"q\""}, ValueType: ValueTypeString}},
+		{{Column: "a", Operator: "exists"}, {Column: "b", Operator: "notexists"}},
+		{leaf("a", "size<=", "3", ValueTypeNumber), leaf("b", "type", "NS", ValueTypeString)},
+		{
+			group(GroupOr,
+				leaf("a", "==", "1", ValueTypeNumber),
+				group(GroupAnd,
+					leaf("b", "==", "x", ValueTypeString),
+					negate(leaf("c", "startswith", "p", ValueTypeString)),
+				),
+			),
+			negate(group(GroupOr,
+				leaf("d", "==", "true", ValueTypeBool),
+				negate(group(GroupAnd,
+					leaf("e", "==", "1", ValueTypeNumber),
+					leaf("f", "==", "2", ValueTypeNumber),
+				)),
+			)),
+		},
+	}
+
+	for _, want := range tests {
+		text := Format(want)
+		t.Run(text, func(t *testing.T) {
+			got, err := Parse(text)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", text, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Parse(Format(x)) != x for %q\n got  %+v\n want %+v", text, got, want)
+			}
+		})
+	}
+}
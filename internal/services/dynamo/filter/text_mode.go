@@ -0,0 +1,185 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SetConditions loads conditions into both the form and the text editor
+func (m *ItemFilterModel) SetConditions(conditions []FilterCondition) {
+	m.Conditions = conditions
+	m.queryInput.SetValue(Format(conditions))
+	m.queryInput.CursorEnd()
+	m.err = nil
+}
+
+// SetColumns sets the column names offered for completion in text mode
+func (m *ItemFilterModel) SetColumns(columns []string) {
+	m.columns = append([]string{}, columns...)
+	sort.Strings(m.columns)
+}
+
+// Apply parses the text editor into Conditions. The form editor builds
+// Conditions as it goes, so there is nothing to do in form mode.
+func (m ItemFilterModel) Apply() (ItemFilterModel, error) {
+	if !m.textMode {
+		return m, nil
+	}
+
+	conditions, err := Parse(m.queryInput.Value())
+	if err != nil {
+		m.err = err
+		return m, err
+	}
+
+	m.Conditions = conditions
+	m.err = nil
+	return m, nil
+}
+
+func (m ItemFilterModel) toggleMode() ItemFilterModel {
+	if m.textMode {
+		// Keep whatever parses; otherwise stay in text mode to show the error
+		parsed, err := m.Apply()
+		if err != nil {
+			return parsed
+		}
+		m = parsed
+		m.queryInput.Blur()
+		m.focusIndex = 0
+		m.columnInput.Focus()
+	} else {
+		m.queryInput.SetValue(Format(m.Conditions))
+		m.queryInput.CursorEnd()
+		m.queryInput.Focus()
+		m.columnInput.Blur()
+		m.operatorInput.Blur()
+		m.valueInput.Blur()
+		m.typeInput.Blur()
+	}
+
+	m.textMode = !m.textMode
+	m.completions = nil
+	m.err = nil
+	return m
+}
+
+func (m ItemFilterModel) updateText(msg tea.Msg) (ItemFilterModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "tab":
+			return m.complete(), nil
+
+		case "enter":
+			// Check the expression without applying it
+			parsed, err := m.Apply()
+			if err == nil {
+				parsed.completions = nil
+			}
+			return parsed, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.queryInput, cmd = m.queryInput.Update(msg)
+	m.completions = nil
+	return m, cmd
+}
+
+// complete expands the column name before the cursor from the known columns
+func (m ItemFilterModel) complete() ItemFilterModel {
+	value := []rune(m.queryInput.Value())
+	pos := m.queryInput.Position()
+
+	start := pos
+	for start > 0 && isIdentPart(value[start-1]) {
+		start--
+	}
+	prefix := string(value[start:pos])
+
+	var matches []string
+	for _, col := range m.columns {
+		if strings.HasPrefix(col, prefix) {
+			matches = append(matches, col)
+		}
+	}
+	if len(matches) == 0 {
+		m.completions = nil
+		return m
+	}
+
+	// Extend to the longest prefix shared by every match
+	completion := matches[0]
+	for _, match := range matches[1:] {
+		for !strings.HasPrefix(match, completion) {
+			completion = completion[:len(completion)-1]
+		}
+	}
+
+	if len(matches) > 1 {
+		m.completions = matches
+	} else {
+		m.completions = nil
+	}
+
+	newValue := string(value[:start]) + completion + string(value[pos:])
+	m.queryInput.SetValue(newValue)
+	m.queryInput.SetCursor(start + len([]rune(completion)))
+	return m
+}
+
+func (m ItemFilterModel) viewText() string {
+	var b strings.Builder
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+
+	b.WriteString("Filter Expression:\n\n")
+	b.WriteString(m.queryInput.View())
+	b.WriteString("\n")
+
+	if m.err != nil {
+		// Point at the offending position under the input
+		var parseErr *ParseError
+		if errors.As(m.err, &parseErr) {
+			offset := lipgloss.Width(m.queryInput.Prompt) + parseErr.Pos
+			b.WriteString(strings.Repeat(" ", offset))
+			b.WriteString(errorStyle.Render("^"))
+			b.WriteString("\n")
+		}
+		b.WriteString(errorStyle.Render(m.err.Error()))
+		b.WriteString("\n")
+	}
+
+	if len(m.completions) > 0 {
+		shown := m.completions
+		if len(shown) > 10 {
+			shown = append(shown[:10:10], fmt.Sprintf("… %d more", len(m.completions)-10))
+		}
+		b.WriteString(helpStyle.Render("Columns: " + strings.Join(shown, "  ")))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(
+		`Operators: = != < <= > >= • between a and b • in (a, b) • contains • startswith • endswith`,
+	))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(
+		`Functions: exists(x) • not_exists(x) • type(x, "N") • size(x) > 3 • and • or • not • ( )`,
+	))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(
+		"Values: \"text\" • 42 • true/false • null • bare words and `any text` use the column's type",
+	))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(
+		"Tab: Complete column • Enter: Check • Ctrl+E: Form mode • Ctrl+S: Apply • Esc: Cancel",
+	))
+
+	return b.String()
+}
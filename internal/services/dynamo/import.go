@@ -0,0 +1,423 @@
+package dynamo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Import file formats, detected from the file
+const (
+	importJSONLines = "json-lines" // One plain or DynamoDB JSON object per line
+	importJSONArray = "json-array" // A JSON array of plain or DynamoDB JSON objects
+	importCSV       = "csv"        // A header row of name or name:TYPE columns
+)
+
+// Problems listed on the dry run screen; the rest are only counted
+const maxImportProblems = 10
+
+type importCheckedMsg struct {
+	path     string
+	format   string
+	records  int
+	problems []string
+	invalid  int // Records that failed validation
+	err      error
+}
+
+func newImportInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "File to import (.jsonl, .json or .csv)"
+	ti.Focus()
+	ti.Width = 60
+	return ti
+}
+
+// importReader reads items one record at a time from an import file
+type importReader struct {
+	format string
+	next   func() (item map[string]types.AttributeValue, where string, err error) // io.EOF after the last record
+}
+
+// openImportReader detects the file format and returns a reader over its records.
+// CSV is picked by extension; JSON files starting with [ are read as an array,
+// anything else as JSON lines.
+func openImportReader(file *os.File) (*importReader, error) {
+	if strings.EqualFold(filepath.Ext(file.Name()), ".csv") {
+		return newCSVImportReader(file)
+	}
+
+	br := bufio.NewReader(file)
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return &importReader{format: importJSONLines, next: func() (map[string]types.AttributeValue, string, error) {
+				return nil, "", io.EOF
+			}}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' || b == 0xEF || b == 0xBB || b == 0xBF {
+			continue
+		}
+		br.UnreadByte()
+		if b == '[' {
+			return newJSONArrayImportReader(br)
+		}
+		return newJSONLinesImportReader(br), nil
+	}
+}
+
+func newJSONLinesImportReader(r io.Reader) *importReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBackupLine)
+	line := 0
+
+	return &importReader{
+		format: importJSONLines,
+		next: func() (map[string]types.AttributeValue, string, error) {
+			for scanner.Scan() {
+				line++
+				text := bytes.TrimSpace(scanner.Bytes())
+				if len(text) == 0 {
+					continue
+				}
+				item, _, err := decodeItem(text)
+				return item, fmt.Sprintf("line %d", line), err
+			}
+			if err := scanner.Err(); err != nil {
+				return nil, fmt.Sprintf("line %d", line+1), err
+			}
+			return nil, "", io.EOF
+		},
+	}
+}
+
+func newJSONArrayImportReader(r io.Reader) (*importReader, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	record := 0
+
+	return &importReader{
+		format: importJSONArray,
+		next: func() (map[string]types.AttributeValue, string, error) {
+			if !dec.More() {
+				return nil, "", io.EOF
+			}
+			record++
+			where := fmt.Sprintf("item %d", record)
+
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				// The rest of the array can't be found after a syntax error
+				return nil, where, fmt.Errorf("invalid JSON: %w", err)
+			}
+			item, _, err := decodeItem(raw)
+			return item, where, err
+		},
+	}, nil
+}
+
+// csvColumn is a CSV header cell; type hints look like age:N. A suffix that
+// isn't a type, like the :b of a:b, is part of the name.
+type csvColumn struct {
+	name     string
+	dataType string // Empty means S
+}
+
+func newCSVImportReader(r io.Reader) (*importReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make([]csvColumn, len(header))
+	for i, cell := range header {
+		cell = strings.TrimPrefix(strings.TrimSpace(cell), "\ufeff")
+		columns[i] = csvColumn{name: cell}
+		if colon := strings.LastIndex(cell, ":"); colon > 0 {
+			if hint := strings.ToUpper(cell[colon+1:]); typeDescriptors[hint] {
+				columns[i] = csvColumn{name: cell[:colon], dataType: hint}
+			}
+		}
+	}
+
+	return &importReader{
+		format: importCSV,
+		next: func() (map[string]types.AttributeValue, string, error) {
+			record, err := cr.Read()
+			if err == io.EOF {
+				return nil, "", io.EOF
+			}
+			if err != nil {
+				// Parse errors carry their own line number
+				return nil, "CSV", err
+			}
+			line, _ := cr.FieldPos(0)
+			where := fmt.Sprintf("line %d", line)
+			if len(record) > len(columns) {
+				return nil, where, fmt.Errorf("%d fields but only %d columns", len(record), len(columns))
+			}
+
+			item := make(map[string]types.AttributeValue, len(record))
+			for i, value := range record {
+				// Empty cells leave the attribute out
+				if value == "" {
+					continue
+				}
+				av, err := csvAttributeValue(value, columns[i].dataType)
+				if err != nil {
+					return nil, where, fmt.Errorf("%s: %w", columns[i].name, err)
+				}
+				item[columns[i].name] = av
+			}
+			return item, where, nil
+		},
+	}, nil
+}
+
+// csvAttributeValue converts a CSV cell using its column's type hint. Sets,
+// lists and maps are written as JSON, the way the CSV export encodes them.
+func csvAttributeValue(value, dataType string) (types.AttributeValue, error) {
+	switch dataType {
+	case "", "S":
+		return &types.AttributeValueMemberS{Value: value}, nil
+	case "N":
+		n, err := numberString(value)
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberN{Value: n}, nil
+	case "BOOL":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", value)
+		}
+		return &types.AttributeValueMemberBOOL{Value: b}, nil
+	case "NULL":
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case "B":
+		b, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("binary values must be base64 encoded")
+		}
+		return &types.AttributeValueMemberB{Value: b}, nil
+	}
+
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%s values must be JSON: %w", dataType, err)
+	}
+
+	switch dataType {
+	case "L", "M":
+		av := plainToAttributeValue(raw)
+		if dataType == "L" {
+			if _, ok := av.(*types.AttributeValueMemberL); !ok {
+				return nil, fmt.Errorf("expected a JSON array")
+			}
+		} else if _, ok := av.(*types.AttributeValueMemberM); !ok {
+			return nil, fmt.Errorf("expected a JSON object")
+		}
+		return av, nil
+	}
+
+	// SS, NS and BS share the DynamoDB JSON parsing of set members
+	return dynamoJSONToAttributeValue(map[string]any{dataType: raw})
+}
+
+// openImportForm asks for the file to import into the selected table
+func (m Model) openImportForm() (tea.Model, tea.Cmd) {
+	m.importInput = newImportInput()
+	m.state = stateImportForm
+	return m, textinput.Blink
+}
+
+func (m Model) updateImportForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.state = stateItemList
+		return m, nil
+
+	case "enter":
+		path, err := expandHome(strings.TrimSpace(m.importInput.Value()))
+		if err != nil || path == "" {
+			return m, nil
+		}
+		m.state = stateLoading
+		return m, m.checkImport(path)
+	}
+
+	var cmd tea.Cmd
+	m.importInput, cmd = m.importInput.Update(msg)
+	return m, cmd
+}
+
+// checkImport is the dry run: it reads every record and checks that it parses,
+// carries the table's key attributes and doesn't repeat a key. Nothing is written.
+func (m Model) checkImport(path string) tea.Cmd {
+	keys := m.tableKeys[m.selectedTable]
+
+	return func() tea.Msg {
+		file, err := os.Open(path)
+		if err != nil {
+			return importCheckedMsg{err: err}
+		}
+		defer file.Close()
+
+		reader, err := openImportReader(file)
+		if err != nil {
+			return importCheckedMsg{err: err}
+		}
+
+		msg := importCheckedMsg{path: path, format: reader.format}
+		problem := func(where string, err error) {
+			msg.invalid++
+			if len(msg.problems) < maxImportProblems {
+				msg.problems = append(msg.problems, fmt.Sprintf("%s: %v", where, err))
+			}
+		}
+
+		// BatchWriteItem rejects a batch that puts the same key twice
+		seen := make(map[string]string)
+		for {
+			item, where, err := reader.next()
+			if err == io.EOF {
+				break
+			}
+			msg.records++
+			if err != nil {
+				problem(where, err)
+				if reader.format == importJSONArray {
+					break
+				}
+				continue
+			}
+
+			if err := validateItemKeys(item, keys); err != nil {
+				problem(where, err)
+				continue
+			}
+			key := keyString(item, keys)
+			if first, ok := seen[key]; ok {
+				problem(where, fmt.Errorf("duplicate key, first seen at %s", first))
+				continue
+			}
+			seen[key] = where
+		}
+
+		return msg
+	}
+}
+
+func (m Model) handleImportChecked(msg importCheckedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.state = stateItemList
+		return m, nil
+	}
+
+	m.importCheck = msg
+	m.state = stateImportReview
+	return m, nil
+}
+
+func (m Model) updateImportReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "enter":
+		if m.importCheck.invalid == 0 && m.importCheck.records > 0 {
+			return m.startImport()
+		}
+
+	case "n", "esc":
+		m.state = stateItemList
+	}
+	return m, nil
+}
+
+// importPager reads an import file in pages of items for a batch job
+func importPager(reader *importReader, keys TableKeySchema) itemPager {
+	const pageSize = 100
+
+	return func(ctx context.Context) ([]map[string]types.AttributeValue, bool, error) {
+		var items []map[string]types.AttributeValue
+
+		for len(items) < pageSize {
+			item, where, err := reader.next()
+			if err == io.EOF {
+				return items, false, nil
+			}
+			if err == nil {
+				err = validateItemKeys(item, keys)
+			}
+			if err != nil {
+				return nil, false, fmt.Errorf("%s: %w", where, err)
+			}
+			items = append(items, item)
+		}
+
+		return items, true, nil
+	}
+}
+
+// startImport writes the checked file into the selected table with BatchWriteItem puts.
+// Items that already exist with the same key are backed up, then overwritten.
+func (m Model) startImport() (tea.Model, tea.Cmd) {
+	backup, err := m.newBackupWriter("import-overwrite")
+	if err != nil {
+		m.err = fmt.Errorf("failed to create backup, nothing was imported: %w", err)
+		m.state = stateItemList
+		return m, nil
+	}
+
+	file, err := os.Open(m.importCheck.path)
+	if err == nil {
+		var reader *importReader
+		if reader, err = openImportReader(file); err == nil {
+			client := m.client
+			tableName := m.selectedTable
+			keys := m.tableKeys[tableName]
+			return m.startBatchJob(batchJob{
+				kind:      jobImport,
+				tableName: tableName,
+				total:     m.importCheck.records,
+				pager:     importPager(reader, keys),
+				requests:  putRequests,
+				backup:    backup,
+				backupOf: func(ctx context.Context, items []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+					// Only the existing items a page replaces
+					return fullItems(ctx, client, tableName, keys, items)
+				},
+				cleanup: func() { file.Close() },
+			})
+		}
+		file.Close()
+	}
+
+	backup.close()
+	m.err = err
+	m.state = stateItemList
+	return m, nil
+}
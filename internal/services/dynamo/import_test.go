@@ -0,0 +1,92 @@
+package dynamo
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// readCSV reads every item of a CSV import, stopping at the first error
+func readCSV(t *testing.T, data string) ([]map[string]types.AttributeValue, error) {
+	t.Helper()
+	reader, err := newCSVImportReader(strings.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []map[string]types.AttributeValue
+	for {
+		item, where, err := reader.next()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return items, fmt.Errorf("%s: %w", where, err)
+		}
+		items = append(items, item)
+	}
+}
+
+func TestCSVImport(t *testing.T) {
+	data := "pk,age:N,ok:bool,gone:NULL,blob:B,tags:SS,nums:NS,blobs:BS,list:L,doc:M,host:port,a:b:S,note:\n" +
+		`1,42,true,x,AQI=,"[""a"",""b""]","[1,""2.5""]","[""AQ==""]","[1,""x""]","{""k"":true}",h:80,y,z` + "\n" +
+		"2,,,,,,,,,,,,\n" +
+		"3\n"
+
+	items, err := readCSV(t, data)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+
+	want := []map[string]types.AttributeValue{
+		{
+			"pk":    avS("1"),
+			"age":   avN("42"),
+			"ok":    &types.AttributeValueMemberBOOL{Value: true},
+			"gone":  &types.AttributeValueMemberNULL{Value: true},
+			"blob":  &types.AttributeValueMemberB{Value: []byte{1, 2}},
+			"tags":  &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+			"nums":  &types.AttributeValueMemberNS{Value: []string{"1", "2.5"}},
+			"blobs": &types.AttributeValueMemberBS{Value: [][]byte{{1}}},
+			"list":  &types.AttributeValueMemberL{Value: []types.AttributeValue{avN("1"), avS("x")}},
+			"doc": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+				"k": &types.AttributeValueMemberBOOL{Value: true},
+			}},
+			// Suffixes that aren't types stay part of the column name
+			"host:port": avS("h:80"),
+			"a:b":       avS("y"),
+			"note:":     avS("z"),
+		},
+		// Empty and missing cells leave the attribute out
+		{"pk": avS("2")},
+		{"pk": avS("3")},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("imported items:\n got %#v\nwant %#v", items, want)
+	}
+}
+
+func TestCSVImportErrors(t *testing.T) {
+	tests := map[string]string{
+		"not a number":     "pk,age:N\n1,old\n",
+		"not a boolean":    "pk,ok:BOOL\n1,maybe\n",
+		"not base64":       "pk,blob:B\n1,!!\n",
+		"set not JSON":     "pk,tags:SS\n1,a;b\n",
+		"set of numbers":   "pk,tags:SS\n1,[1]\n",
+		"number set":       "pk,nums:NS\n1,\"[\"\"x\"\"]\"\n",
+		"list not array":   "pk,list:L\n1,{}\n",
+		"map not object":   "pk,doc:M\n1,[]\n",
+		"too many fields":  "pk,a\n1,2,3\n",
+		"unbalanced quote": "pk\n\"1\n",
+	}
+
+	for name, data := range tests {
+		if _, err := readCSV(t, data); err == nil {
+			t.Errorf("%s: import succeeded, want an error", name)
+		}
+	}
+}
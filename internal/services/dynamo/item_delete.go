@@ -0,0 +1,261 @@
+package dynamo
+
+import (
+	"cirrus/internal/messages"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type itemDeletedMsg struct {
+	deleted    []map[string]types.AttributeValue // Keys of the deleted items
+	old        []map[string]types.AttributeValue // Full items returned by DeleteItem, when kept for undo
+	failed     int
+	err        error // First failure, if any
+	backupPath string
+}
+
+type itemsRestoredMsg struct {
+	restored []map[string]types.AttributeValue
+	retry    []map[string]types.AttributeValue // Failed for reasons other than the key being taken again
+	failed   int
+	err      error
+}
+
+// toggleMark marks or unmarks the row under the cursor for a multi-item delete
+func (m Model) toggleMark() (tea.Model, tea.Cmd) {
+	if cmd := m.refusePartiQLRows("mark"); cmd != nil {
+		return m, cmd
+	}
+	cursor := m.itemTable.Cursor()
+	if cursor < 0 || cursor >= len(m.items) {
+		return m, nil
+	}
+
+	if m.markedItems == nil {
+		m.markedItems = make(map[string]bool)
+	}
+	key := keyString(m.items[cursor], m.tableKeys[m.selectedTable])
+	if m.markedItems[key] {
+		delete(m.markedItems, key)
+	} else {
+		m.markedItems[key] = true
+	}
+
+	m.rebuildItemTable(cursor)
+	m.itemTable.MoveDown(1)
+	return m, nil
+}
+
+// markedRows returns the loaded items that are marked for deletion
+func (m Model) markedRows() []map[string]types.AttributeValue {
+	keys := m.tableKeys[m.selectedTable]
+
+	var rows []map[string]types.AttributeValue
+	for _, item := range m.items {
+		if m.markedItems[keyString(item, keys)] {
+			rows = append(rows, item)
+		}
+	}
+	return rows
+}
+
+// confirmDeleteItems asks before deleting the given items
+func (m Model) confirmDeleteItems(items []map[string]types.AttributeValue) (tea.Model, tea.Cmd) {
+	if len(items) == 0 {
+		return m, nil
+	}
+	if cmd := m.refusePartiQLRows("delete"); cmd != nil {
+		return m, cmd
+	}
+
+	keys := m.tableKeys[m.selectedTable]
+	for _, item := range items {
+		if err := validateItemKeys(itemKey(item, keys), keys); err != nil {
+			return m, messages.ShowToast("These results don't include the primary key, so they can't be deleted", messages.ToastWarning)
+		}
+	}
+
+	m.deleteTargets = items
+	m.keepUndo = true
+	m.previousState = m.state
+	m.state = stateItemDeleteConfirm
+	return m, nil
+}
+
+func (m Model) updateItemDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y":
+		m.state = stateLoading
+		return m, m.deleteItems(m.deleteTargets, m.keepUndo)
+
+	case "u":
+		m.keepUndo = !m.keepUndo
+		return m, nil
+
+	case "n", "esc":
+		m.deleteTargets = nil
+		m.state = m.previousState
+		return m, nil
+	}
+	return m, nil
+}
+
+// deleteItems deletes items one by one by primary key. Each delete is
+// conditional on the item still existing, so rows someone else already removed
+// are reported instead of silently counted.
+func (m Model) deleteItems(items []map[string]types.AttributeValue, keepOld bool) tea.Cmd {
+	tableName := m.selectedTable
+	keys := m.tableKeys[tableName]
+
+	return func() tea.Msg {
+		var msg itemDeletedMsg
+
+		path, err := m.backupItems("delete", items)
+		if err != nil {
+			return itemDeletedMsg{err: err}
+		}
+		msg.backupPath = path
+
+		for _, item := range items {
+			input := &dynamodb.DeleteItemInput{
+				TableName:                aws.String(tableName),
+				Key:                      itemKey(item, keys),
+				ConditionExpression:      aws.String("attribute_exists(#pk)"),
+				ExpressionAttributeNames: map[string]string{"#pk": keys.PartitionKey},
+			}
+			if keepOld {
+				input.ReturnValues = types.ReturnValueAllOld
+			}
+
+			result, err := m.client.DeleteItem(context.TODO(), input)
+			if err != nil {
+				var conditionErr *types.ConditionalCheckFailedException
+				if errors.As(err, &conditionErr) {
+					err = fmt.Errorf("item no longer exists")
+				}
+				msg.failed++
+				if msg.err == nil {
+					msg.err = err
+				}
+				continue
+			}
+
+			msg.deleted = append(msg.deleted, input.Key)
+			if keepOld && len(result.Attributes) > 0 {
+				msg.old = append(msg.old, result.Attributes)
+			}
+		}
+
+		return msg
+	}
+}
+
+func (m Model) handleItemDeleted(msg itemDeletedMsg) (tea.Model, tea.Cmd) {
+	keys := m.tableKeys[m.selectedTable]
+	m.deleteTargets = nil
+
+	// Drop the deleted rows without reloading the table
+	deleted := make(map[string]bool, len(msg.deleted))
+	for _, key := range msg.deleted {
+		deleted[keyString(key, keys)] = true
+	}
+
+	remaining := make([]map[string]types.AttributeValue, 0, len(m.items))
+	for _, item := range m.items {
+		key := keyString(item, keys)
+		if deleted[key] {
+			delete(m.markedItems, key)
+			continue
+		}
+		remaining = append(remaining, item)
+	}
+	m.items = remaining
+
+	if len(msg.old) > 0 {
+		m.undoBuffer = msg.old
+	}
+
+	cursor := max(min(m.itemTable.Cursor(), len(m.items)-1), 0)
+	m.rebuildItemTable(cursor)
+	m.selectedIdx = cursor
+	m.state = stateItemList
+
+	if msg.err != nil && len(msg.deleted) == 0 && msg.failed == 0 {
+		// Nothing was attempted, e.g. the backup failed
+		m.err = msg.err
+		return m, nil
+	}
+	if msg.err != nil {
+		return m, messages.ShowToast(
+			fmt.Sprintf("Deleted %d item(s), %d failed: %v • backup: %s", len(msg.deleted), msg.failed, msg.err, msg.backupPath),
+			messages.ToastError,
+		)
+	}
+
+	text := fmt.Sprintf("Deleted %d item(s)", len(msg.deleted))
+	if len(msg.old) > 0 {
+		text += " • U: Undo"
+	}
+	text += " • backup: " + msg.backupPath
+	return m, messages.ShowToast(text, messages.ToastSuccess)
+}
+
+// restoreItems puts the undo buffer back, without overwriting items that were
+// recreated in the meantime
+func (m Model) restoreItems() tea.Cmd {
+	tableName := m.selectedTable
+	keys := m.tableKeys[tableName]
+	items := m.undoBuffer
+
+	return func() tea.Msg {
+		var msg itemsRestoredMsg
+
+		for _, item := range items {
+			_, err := m.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+				TableName:                aws.String(tableName),
+				Item:                     item,
+				ConditionExpression:      aws.String("attribute_not_exists(#pk)"),
+				ExpressionAttributeNames: map[string]string{"#pk": keys.PartitionKey},
+			})
+			if err != nil {
+				var conditionErr *types.ConditionalCheckFailedException
+				if errors.As(err, &conditionErr) {
+					err = fmt.Errorf("an item with the same key exists again")
+				} else {
+					msg.retry = append(msg.retry, item)
+				}
+				msg.failed++
+				if msg.err == nil {
+					msg.err = err
+				}
+				continue
+			}
+			msg.restored = append(msg.restored, item)
+		}
+
+		return msg
+	}
+}
+
+func (m Model) handleItemsRestored(msg itemsRestoredMsg) (tea.Model, tea.Cmd) {
+	// Items that failed to restore, e.g. when throttled, can be undone again
+	m.undoBuffer = msg.retry
+	m.items = append(m.items, msg.restored...)
+	m.rebuildItemTable(m.itemTable.Cursor())
+	m.state = stateItemList
+
+	if msg.err != nil {
+		text := fmt.Sprintf("Restored %d item(s), %d failed: %v", len(msg.restored), msg.failed, msg.err)
+		if len(msg.retry) > 0 {
+			text += " • U: Retry"
+		}
+		return m, messages.ShowToast(text, messages.ToastError)
+	}
+	return m, messages.ShowToast(fmt.Sprintf("Restored %d item(s)", len(msg.restored)), messages.ToastSuccess)
+}
@@ -13,6 +13,7 @@ type ItemTableParams struct {
 	SortKey         string
 	Items           []map[string]types.AttributeValue
 	FilteredColumns []string
+	IsMarked        func(item map[string]types.AttributeValue) bool // Optional, flags rows selected for a bulk action
 }
 
 func BuildItemTable(
@@ -26,7 +27,7 @@ func BuildItemTable(
 
 	// Extract all unique columns from items
 	columns := extractColumns(p)
-	rows := buildRows(columns, p.Items)
+	rows := buildRows(columns, p.Items, p.IsMarked)
 
 	t := table.New(
 		table.WithColumns(columns),
@@ -81,7 +82,7 @@ func extractColumns(
 			// Only include columns that actually exist in the data
 			exists := false
 			for _, item := range p.Items {
-				if _, ok := item[colName]; ok {
+				if _, ok := resolveAttributePath(item, colName); ok {
 					exists = true
 					break
 				}
@@ -138,18 +139,22 @@ func extractColumns(
 func buildRows(
 	columns []table.Column,
 	items []map[string]types.AttributeValue,
+	isMarked func(item map[string]types.AttributeValue) bool,
 ) []table.Row {
 	rows := make([]table.Row, len(items))
 
 	for i, item := range items {
 		row := make(table.Row, len(columns))
 		for j, col := range columns {
-			if val, ok := item[col.Title]; ok {
+			if val, ok := resolveAttributePath(item, col.Title); ok {
 				row[j] = formatAttributeValueCompact(val)
 			} else {
 				row[j] = "-"
 			}
 		}
+		if isMarked != nil && isMarked(item) && len(row) > 0 {
+			row[0] = "● " + row[0]
+		}
 		rows[i] = row
 	}
 
@@ -196,7 +201,7 @@ func calculateColumnWidth(columnName string, items []map[string]types.AttributeV
 	// Sample first 10 items to determine width
 	sampleSize := min(10, len(items))
 	for i := 0; i < sampleSize; i++ {
-		if val, ok := items[i][columnName]; ok {
+		if val, ok := resolveAttributePath(items[i], columnName); ok {
 			formatted := formatAttributeValueCompact(val)
 			if len(formatted) > maxWidth {
 				maxWidth = len(formatted)
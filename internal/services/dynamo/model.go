@@ -3,10 +3,13 @@ package dynamo
 import (
 	"cirrus/internal/config"
 	"cirrus/internal/services/dynamo/filter"
+	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -22,7 +25,26 @@ const (
 	stateColumnFilter
 	stateItemFilter
 	stateDeleteConfirm
-	stateDeleting
+	stateBatchRunning
+	stateQueryForm
+	stateIndexPicker
+	statePartiQL
+	stateItemEditor
+	stateItemSaveConfirm
+	stateItemDeleteConfirm
+	stateBackupList
+	stateExportForm
+	stateExporting
+	stateImportForm
+	stateImportReview
+	stateCopyTargetPicker
+	stateCopyOptions
+	stateCopyReport
+	stateDiffPicker
+	stateDiffRunning
+	stateDiffResults
+	stateDiffDetail
+	stateTableInfo
 )
 
 // Model represents the DynamoDB child model
@@ -39,12 +61,45 @@ type Model struct {
 	tables        []string
 	selectedTable string
 	tableKeys     map[string]TableKeySchema
+	tableIndexes  map[string][]TableIndex
+	selectedIndex string // Empty means the base table
+	indexCursor   int
 	items         []map[string]types.AttributeValue
 	allColumns    []string // All available columns
 
+	// Pagination (nil LastEvaluatedKey means the result set is complete)
+	lastEvaluatedKey map[string]types.AttributeValue
+
 	// Filters (applied server-side)
 	activeFilters []filter.FilterCondition
 	itemFilter    filter.ItemFilterModel
+	columnTypes   map[string]string // Sampled value type per column, for inferred filter types
+
+	// Key-condition query (nil means the item list is a Scan)
+	activeQuery *KeyQuery
+	queryForm   QueryFormModel
+
+	// PartiQL console (a non-empty statement means the item list shows its results)
+	partiqlInput      textarea.Model
+	partiqlStatement  string
+	partiqlNextToken  *string
+	partiqlHistoryIdx int
+	partiqlConfirm    bool
+
+	// Item editor (editOriginal is nil when creating a new item)
+	editorInput     textarea.Model
+	editorFormat    string
+	editOriginal    map[string]types.AttributeValue
+	editItem        map[string]types.AttributeValue // Parsed editor content waiting for confirmation
+	editDiff        []attributeDiff
+	editConditional bool // Only save if the item is unchanged since it was loaded
+	editErr         error
+
+	// Single and multi-item delete
+	markedItems   map[string]bool // Rows marked for deletion, by primary key
+	deleteTargets []map[string]types.AttributeValue
+	keepUndo      bool
+	undoBuffer    []map[string]types.AttributeValue // Items removed by the last delete, for undo
 
 	// UI components
 	itemTable    table.Model
@@ -54,8 +109,62 @@ type Model struct {
 
 	// Delete tracking
 	confirmInput     textinput.Model
-	deleteTotal      int
 	loadingForDelete bool
+	deleteMatching   bool // The confirm screen is deleting by filter, not emptying the table
+	deleteKeys       []map[string]types.AttributeValue
+	deleteGone       *int // Confirmed keys the running delete found gone
+	emptyCount       int  // Items counted before emptying the table
+
+	// Background batch job (bulk delete, restore)
+	batchJob       batchJob
+	batchCancel    context.CancelFunc // Stops the running job
+	batchCancelled bool
+	batchUpdates   <-chan tea.Msg
+	batchProgress  batchProgressMsg
+	batchStarted   time.Time
+
+	// Backups of the table picked on the table list
+	backups      []backupInfo
+	backupCursor int
+	restoring    bool // Asking to confirm a restore of the backup under the cursor
+
+	// Export of the item list result set
+	exportForm    ExportFormModel
+	exportCancel  context.CancelFunc
+	exportUpdates <-chan tea.Msg
+	exportWritten int
+
+	// Import into the selected table (importCheck is the dry run result)
+	importInput textinput.Model
+	importCheck importCheckedMsg
+
+	// Copy to another table (copyItems is nil when copying the whole result set)
+	copyItems     []map[string]types.AttributeValue
+	copyTargets   []string
+	copyCursor    int
+	copyPlan      copyPlan
+	copyFromInput textinput.Model
+	copyToInput   textinput.Model
+	copyErr       error
+	copyReport    *copyReport
+
+	// Metadata of the table picked on the table list
+	tableInfo *tableInfo
+
+	// Table diff (the left side is the selected table)
+	diffTables       []string
+	diffCursor       int
+	diffFileInput    textinput.Model
+	diffLeft         diffSource
+	diffRight        diffSource
+	diffCancel       context.CancelFunc
+	diffUpdates      <-chan tea.Msg
+	diffProgress     diffProgressMsg
+	diffResult       *tableDiff
+	diffFilter       int // diffFilterAll or a diffKind
+	diffResultCursor int
+	diffDetailEntry  diffEntry
+	diffDetail       []attributeDiff
 
 	// Dimensions
 	Width  int
@@ -64,8 +173,18 @@ type Model struct {
 
 // TableKeySchema holds primary key information
 type TableKeySchema struct {
-	PartitionKey string
-	SortKey      string
+	PartitionKey     string
+	SortKey          string
+	PartitionKeyType types.ScalarAttributeType
+	SortKeyType      types.ScalarAttributeType
+}
+
+// TableIndex describes a global or local secondary index
+type TableIndex struct {
+	Name       string
+	Global     bool
+	Keys       TableKeySchema
+	Projection string
 }
 
 // NewModel creates a new DynamoDB model
@@ -77,11 +196,12 @@ func NewModel(client *dynamodb.Client, env string) Model {
 	}
 
 	return Model{
-		client:    client,
-		config:    cfg,
-		state:     stateTableList,
-		tableKeys: make(map[string]TableKeySchema),
-		env:       env,
+		client:       client,
+		config:       cfg,
+		state:        stateTableList,
+		tableKeys:    make(map[string]TableKeySchema),
+		tableIndexes: make(map[string][]TableIndex),
+		env:          env,
 	}
 }
 
@@ -89,3 +209,29 @@ func NewModel(client *dynamodb.Client, env string) Model {
 func (m Model) Init() tea.Cmd {
 	return m.loadTables()
 }
+
+// activeKeys returns the key schema of the selected index, or of the table itself
+func (m Model) activeKeys() TableKeySchema {
+	for _, idx := range m.tableIndexes[m.selectedTable] {
+		if idx.Name == m.selectedIndex {
+			return idx.Keys
+		}
+	}
+	return m.tableKeys[m.selectedTable]
+}
+
+// rowsArePartial reports whether the item list reads an index that doesn't
+// project every attribute, so its rows aren't complete items
+func (m Model) rowsArePartial() bool {
+	for _, idx := range m.tableIndexes[m.selectedTable] {
+		if idx.Name == m.selectedIndex {
+			return idx.Projection != string(types.ProjectionTypeAll)
+		}
+	}
+	return false
+}
+
+// hasMorePages reports whether the item list result set is incomplete
+func (m Model) hasMorePages() bool {
+	return m.lastEvaluatedKey != nil || m.partiqlNextToken != nil
+}
@@ -0,0 +1,238 @@
+package dynamo
+
+import (
+	"cirrus/internal/messages"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type partiqlResultMsg struct {
+	items      []map[string]types.AttributeValue
+	nextToken  *string
+	appendPage bool
+	write      bool
+	err        error
+}
+
+func newPartiQLInput(width int) textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "SELECT * FROM \"table\" WHERE ..."
+	ta.ShowLineNumbers = false
+	ta.SetWidth(max(width-4, 40))
+	ta.SetHeight(6)
+	ta.Focus()
+	return ta
+}
+
+// isWriteStatement reports whether a statement modifies data
+func isWriteStatement(statement string) bool {
+	fields := strings.Fields(statement)
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch strings.ToUpper(fields[0]) {
+	case "INSERT", "UPDATE", "DELETE":
+		return true
+	}
+	return false
+}
+
+// refusePartiQLRows stops an action on PartiQL result rows. The statement may
+// have read another table or projected only some attributes, so its rows can't
+// be written back to the selected table. It returns nil outside PartiQL results.
+func (m Model) refusePartiQLRows(action string) tea.Cmd {
+	if m.partiqlStatement == "" {
+		return nil
+	}
+	return messages.ShowToast(fmt.Sprintf("Leave PartiQL results (Ctrl+X in the console) to %s items", action), messages.ToastWarning)
+}
+
+// executeStatement runs one page of a PartiQL statement
+func (m Model) executeStatement(statement string, nextToken *string, appendPage bool) tea.Cmd {
+	return func() tea.Msg {
+		result, err := m.client.ExecuteStatement(context.TODO(), &dynamodb.ExecuteStatementInput{
+			Statement: aws.String(statement),
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return partiqlResultMsg{err: err}
+		}
+
+		return partiqlResultMsg{
+			items:      result.Items,
+			nextToken:  result.NextToken,
+			appendPage: appendPage,
+			write:      isWriteStatement(statement),
+		}
+	}
+}
+
+// executeRemainingStatement follows NextToken until the statement has no more results
+func (m Model) executeRemainingStatement() tea.Cmd {
+	statement := m.partiqlStatement
+	nextToken := m.partiqlNextToken
+
+	return func() tea.Msg {
+		var items []map[string]types.AttributeValue
+
+		for nextToken != nil {
+			result, err := m.client.ExecuteStatement(context.TODO(), &dynamodb.ExecuteStatementInput{
+				Statement: aws.String(statement),
+				NextToken: nextToken,
+			})
+			if err != nil {
+				return partiqlResultMsg{err: err}
+			}
+			items = append(items, result.Items...)
+			nextToken = result.NextToken
+		}
+
+		return partiqlResultMsg{items: items, appendPage: true}
+	}
+}
+
+func (m Model) openPartiQLConsole() (tea.Model, tea.Cmd) {
+	m.partiqlInput = newPartiQLInput(m.Width)
+	m.partiqlHistoryIdx = -1
+	m.partiqlConfirm = false
+
+	if m.partiqlStatement != "" {
+		m.partiqlInput.SetValue(m.partiqlStatement)
+	} else {
+		m.partiqlInput.SetValue(fmt.Sprintf("SELECT * FROM \"%s\"", m.selectedTable))
+	}
+
+	m.state = statePartiQL
+	return m, textarea.Blink
+}
+
+func (m Model) updatePartiQL(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// Write statements wait for an explicit y/n
+		if m.partiqlConfirm {
+			switch msg.String() {
+			case "y":
+				m.partiqlConfirm = false
+				return m.runPartiQL()
+			case "n", "esc":
+				m.partiqlConfirm = false
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "esc":
+			m.state = stateItemList
+			return m, nil
+
+		case "ctrl+s":
+			if strings.TrimSpace(m.partiqlInput.Value()) == "" {
+				return m, nil
+			}
+			if isWriteStatement(m.partiqlInput.Value()) {
+				m.partiqlConfirm = true
+				return m, nil
+			}
+			return m.runPartiQL()
+
+		case "ctrl+x":
+			// Leave PartiQL results and go back to scanning the table
+			m.partiqlStatement = ""
+			m.partiqlNextToken = nil
+			m.state = stateLoading
+			return m, m.loadItems(m.activeFilters)
+
+		case "ctrl+p":
+			// Older statement from history
+			history := m.config.GetPartiQLHistory(m.selectedTable)
+			if m.partiqlHistoryIdx < len(history)-1 {
+				m.partiqlHistoryIdx++
+				m.partiqlInput.SetValue(history[m.partiqlHistoryIdx])
+			}
+			return m, nil
+
+		case "ctrl+n":
+			// Newer statement from history
+			history := m.config.GetPartiQLHistory(m.selectedTable)
+			if m.partiqlHistoryIdx > 0 {
+				m.partiqlHistoryIdx--
+				m.partiqlInput.SetValue(history[m.partiqlHistoryIdx])
+			} else if m.partiqlHistoryIdx == 0 {
+				m.partiqlHistoryIdx = -1
+				m.partiqlInput.SetValue(fmt.Sprintf("SELECT * FROM \"%s\"", m.selectedTable))
+			}
+			return m, nil
+		}
+	}
+
+	m.partiqlInput, cmd = m.partiqlInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) runPartiQL() (tea.Model, tea.Cmd) {
+	statement := strings.TrimSpace(m.partiqlInput.Value())
+
+	var cmds []tea.Cmd
+	m.config.AddPartiQLStatement(m.selectedTable, statement)
+	if err := m.config.Save(); err != nil {
+		cmds = append(cmds, messages.ShowToast("Failed to save PartiQL history", messages.ToastError))
+	}
+	m.partiqlHistoryIdx = -1
+
+	if !isWriteStatement(statement) {
+		m.partiqlStatement = statement
+	}
+
+	m.state = stateLoading
+	cmds = append(cmds, m.executeStatement(statement, nil, false))
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) handlePartiQLResult(msg partiqlResultMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		// Back to the console so the statement can be fixed
+		m.err = msg.err
+		m.state = statePartiQL
+		return m, nil
+	}
+
+	if msg.write {
+		m.state = statePartiQL
+		return m, messages.ShowToast("Statement executed", messages.ToastSuccess)
+	}
+
+	return m.handleItemsLoaded(itemsLoadedMsg{
+		items:      msg.items,
+		nextToken:  msg.nextToken,
+		appendPage: msg.appendPage,
+	})
+}
+
+// partiqlPager pages through every result of a statement
+func (m Model) partiqlPager(statement string) itemPager {
+	client := m.client
+	var nextToken *string
+
+	return func(ctx context.Context) ([]map[string]types.AttributeValue, bool, error) {
+		result, err := client.ExecuteStatement(ctx, &dynamodb.ExecuteStatementInput{
+			Statement: aws.String(statement),
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		nextToken = result.NextToken
+		return result.Items, nextToken != nil, nil
+	}
+}
@@ -0,0 +1,139 @@
+package dynamo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// pathSegment is one map key of a document path plus any list indexes after it,
+// e.g. events[0] is {name: "events", indexes: [0]}
+type pathSegment struct {
+	name    string
+	indexes []int
+}
+
+// parseAttributePath splits a document path like payload.customer.id or
+// events[0].type into segments. Names containing dots or brackets can be
+// wrapped in backticks.
+func parseAttributePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	i := 0
+
+	for {
+		var seg pathSegment
+
+		if i < len(path) && path[i] == '`' {
+			end := strings.IndexByte(path[i+1:], '`')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated ` in path %q", path)
+			}
+			seg.name = path[i+1 : i+1+end]
+			i += end + 2
+		} else {
+			start := i
+			for i < len(path) && path[i] != '.' && path[i] != '[' {
+				i++
+			}
+			seg.name = path[start:i]
+		}
+
+		if seg.name == "" {
+			return nil, fmt.Errorf("empty attribute name in path %q", path)
+		}
+
+		for i < len(path) && path[i] == '[' {
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in path %q", path)
+			}
+			idx, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("invalid list index %q in path %q", path[i+1:i+end], path)
+			}
+			seg.indexes = append(seg.indexes, idx)
+			i += end + 1
+		}
+
+		segments = append(segments, seg)
+
+		if i == len(path) {
+			return segments, nil
+		}
+		if path[i] != '.' {
+			return nil, fmt.Errorf("unexpected %q in path %q", path[i], path)
+		}
+		i++
+	}
+}
+
+// isNestedPath reports whether a column refers into a map or list
+func isNestedPath(path string) bool {
+	segments, err := parseAttributePath(path)
+	return err == nil && (len(segments) > 1 || len(segments[0].indexes) > 0)
+}
+
+// resolveAttributePath looks up a top-level column or a nested document path in an item
+func resolveAttributePath(item map[string]types.AttributeValue, path string) (types.AttributeValue, bool) {
+	// Plain attribute names win, even if they contain dots
+	if av, ok := item[path]; ok {
+		return av, true
+	}
+
+	segments, err := parseAttributePath(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var current types.AttributeValue = &types.AttributeValueMemberM{Value: item}
+	for _, seg := range segments {
+		m, ok := current.(*types.AttributeValueMemberM)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m.Value[seg.name]; !ok {
+			return nil, false
+		}
+
+		for _, idx := range seg.indexes {
+			l, ok := current.(*types.AttributeValueMemberL)
+			if !ok || idx >= len(l.Value) {
+				return nil, false
+			}
+			current = l.Value[idx]
+		}
+	}
+
+	return current, true
+}
+
+// pathExpression builds the expression for a document path, adding one
+// ExpressionAttributeNames placeholder per segment
+func pathExpression(path string, prefix string, names map[string]string) (string, error) {
+	segments, err := parseAttributePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the single placeholder for plain attribute names
+	if len(segments) == 1 && len(segments[0].indexes) == 0 {
+		names[prefix] = segments[0].name
+		return prefix, nil
+	}
+
+	var parts []string
+	for j, seg := range segments {
+		nameKey := fmt.Sprintf("%s_%d", prefix, j)
+		names[nameKey] = seg.name
+
+		part := nameKey
+		for _, idx := range seg.indexes {
+			part += fmt.Sprintf("[%d]", idx)
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, "."), nil
+}
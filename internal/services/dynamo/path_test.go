@@ -0,0 +1,100 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestParseAttributePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []pathSegment
+	}{
+		{"name", []pathSegment{{name: "name"}}},
+		{"a.b[0].c", []pathSegment{{name: "a"}, {name: "b", indexes: []int{0}}, {name: "c"}}},
+		{"events[1][12]", []pathSegment{{name: "events", indexes: []int{1, 12}}}},
+		{"`odd.name[x]`.child", []pathSegment{{name: "odd.name[x]"}, {name: "child"}}},
+		{"a.`b c`[2]", []pathSegment{{name: "a"}, {name: "b c", indexes: []int{2}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseAttributePath(tt.path)
+			if err != nil {
+				t.Fatalf("parseAttributePath(%q) failed: %v", tt.path, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAttributePath(%q) = %+v, want %+v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	for _, path := range []string{"", "a.", ".a", "a..b", "a[", "a[x]", "a[-1]", "a[0]b", "`a", "``"} {
+		if _, err := parseAttributePath(path); err == nil {
+			t.Errorf("parseAttributePath(%q) succeeded, want an error", path)
+		}
+	}
+}
+
+func TestPathExpression(t *testing.T) {
+	tests := []struct {
+		path      string
+		wantExpr  string
+		wantNames map[string]string
+	}{
+		{"name", "#p", map[string]string{"#p": "name"}},
+		{"`a.b`", "#p", map[string]string{"#p": "a.b"}},
+		{"list[3]", "#p_0[3]", map[string]string{"#p_0": "list"}},
+		{"a.b[0].c", "#p_0.#p_1[0].#p_2", map[string]string{"#p_0": "a", "#p_1": "b", "#p_2": "c"}},
+		{"a.a.a", "#p_0.#p_1.#p_2", map[string]string{"#p_0": "a", "#p_1": "a", "#p_2": "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			names := make(map[string]string)
+			expr, err := pathExpression(tt.path, "#p", names)
+			if err != nil {
+				t.Fatalf("pathExpression(%q) failed: %v", tt.path, err)
+			}
+			if expr != tt.wantExpr {
+				t.Errorf("pathExpression(%q) = %q, want %q", tt.path, expr, tt.wantExpr)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("pathExpression(%q) names = %v, want %v", tt.path, names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestResolveAttributePath(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"a.b": avS("dotted"),
+		"a": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"b": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+				&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"c": avN("1")}},
+			}},
+		}},
+	}
+
+	tests := []struct {
+		path string
+		want types.AttributeValue
+	}{
+		// A plain attribute name wins over the nested path it looks like
+		{"a.b", avS("dotted")},
+		{"a.b[0].c", avN("1")},
+		{"a.b[1].c", nil},
+		{"a.c", nil},
+		{"a.b.c", nil},
+		{"missing", nil},
+	}
+
+	for _, tt := range tests {
+		got, ok := resolveAttributePath(item, tt.path)
+		if ok != (tt.want != nil) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("resolveAttributePath(%q) = %v, %v, want %v", tt.path, got, ok, tt.want)
+		}
+	}
+}
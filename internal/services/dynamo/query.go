@@ -0,0 +1,251 @@
+package dynamo
+
+import (
+	"cirrus/internal/styles"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Sort key operators supported by a key condition
+var sortKeyOperators = []string{"=", "<", "<=", ">", ">=", "between", "begins_with"}
+
+// KeyQuery describes a key-condition Query against the selected table
+type KeyQuery struct {
+	PartitionValue string
+	SortOperator   string // Empty means no sort key condition
+	SortValue      string
+	SortValue2     string // Upper bound for between
+	ScanForward    bool
+}
+
+// String renders the query for headers and badges
+func (q KeyQuery) String(keys TableKeySchema) string {
+	s := fmt.Sprintf("%s = %s", keys.PartitionKey, q.PartitionValue)
+
+	switch q.SortOperator {
+	case "":
+	case "between":
+		s += fmt.Sprintf(" AND %s between %s and %s", keys.SortKey, q.SortValue, q.SortValue2)
+	default:
+		s += fmt.Sprintf(" AND %s %s %s", keys.SortKey, q.SortOperator, q.SortValue)
+	}
+
+	if q.ScanForward {
+		return s + " (ascending)"
+	}
+	return s + " (descending)"
+}
+
+type QueryFormModel struct {
+	keys           TableKeySchema
+	partitionInput textinput.Model
+	operatorInput  textinput.Model
+	sortInput      textinput.Model
+	sortInput2     textinput.Model
+	scanForward    bool
+	focusIndex     int
+	err            error
+}
+
+type QuerySubmittedMsg struct {
+	Query KeyQuery
+}
+
+func NewQueryFormModel(keys TableKeySchema, current *KeyQuery) QueryFormModel {
+	pkInput := textinput.New()
+	pkInput.Placeholder = fmt.Sprintf("%s value", keys.PartitionKey)
+	pkInput.Focus()
+	pkInput.Width = 40
+
+	opInput := textinput.New()
+	opInput.Placeholder = "Operator (=, <, <=, >, >=, between, begins_with)"
+	opInput.Width = 40
+
+	skInput := textinput.New()
+	skInput.Placeholder = fmt.Sprintf("%s value", keys.SortKey)
+	skInput.Width = 40
+
+	skInput2 := textinput.New()
+	skInput2.Placeholder = "Upper bound (between only)"
+	skInput2.Width = 40
+
+	m := QueryFormModel{
+		keys:           keys,
+		partitionInput: pkInput,
+		operatorInput:  opInput,
+		sortInput:      skInput,
+		sortInput2:     skInput2,
+		scanForward:    true,
+	}
+
+	// Pre-fill with the query currently in use
+	if current != nil {
+		m.partitionInput.SetValue(current.PartitionValue)
+		m.operatorInput.SetValue(current.SortOperator)
+		m.sortInput.SetValue(current.SortValue)
+		m.sortInput2.SetValue(current.SortValue2)
+		m.scanForward = current.ScanForward
+	}
+
+	return m
+}
+
+func (m QueryFormModel) inputCount() int {
+	// Tables without a sort key only have the partition value
+	if m.keys.SortKey == "" {
+		return 1
+	}
+	return 4
+}
+
+func (m QueryFormModel) Update(msg tea.Msg) (QueryFormModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "tab", "shift+tab":
+			if msg.String() == "tab" {
+				m.focusIndex = (m.focusIndex + 1) % m.inputCount()
+			} else {
+				m.focusIndex--
+				if m.focusIndex < 0 {
+					m.focusIndex = m.inputCount() - 1
+				}
+			}
+
+			m.partitionInput.Blur()
+			m.operatorInput.Blur()
+			m.sortInput.Blur()
+			m.sortInput2.Blur()
+
+			switch m.focusIndex {
+			case 0:
+				m.partitionInput.Focus()
+			case 1:
+				m.operatorInput.Focus()
+			case 2:
+				m.sortInput.Focus()
+			case 3:
+				m.sortInput2.Focus()
+			}
+			return m, nil
+
+		case "ctrl+o":
+			// Toggle sort order
+			m.scanForward = !m.scanForward
+			return m, nil
+
+		case "enter":
+			query, err := m.buildQuery()
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.err = nil
+			return m, func() tea.Msg {
+				return QuerySubmittedMsg{Query: query}
+			}
+		}
+	}
+
+	switch m.focusIndex {
+	case 0:
+		m.partitionInput, cmd = m.partitionInput.Update(msg)
+	case 1:
+		m.operatorInput, cmd = m.operatorInput.Update(msg)
+	case 2:
+		m.sortInput, cmd = m.sortInput.Update(msg)
+	case 3:
+		m.sortInput2, cmd = m.sortInput2.Update(msg)
+	}
+
+	return m, cmd
+}
+
+func (m QueryFormModel) buildQuery() (KeyQuery, error) {
+	q := KeyQuery{
+		PartitionValue: m.partitionInput.Value(),
+		SortOperator:   strings.ToLower(strings.TrimSpace(m.operatorInput.Value())),
+		SortValue:      m.sortInput.Value(),
+		SortValue2:     m.sortInput2.Value(),
+		ScanForward:    m.scanForward,
+	}
+
+	if q.PartitionValue == "" {
+		return q, fmt.Errorf("a %s value is required", m.keys.PartitionKey)
+	}
+
+	if q.SortOperator == "" {
+		q.SortValue = ""
+		q.SortValue2 = ""
+		return q, nil
+	}
+
+	valid := false
+	for _, op := range sortKeyOperators {
+		if op == q.SortOperator {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return q, fmt.Errorf("unknown sort key operator %q", q.SortOperator)
+	}
+
+	if q.SortValue == "" {
+		return q, fmt.Errorf("a %s value is required for %s", m.keys.SortKey, q.SortOperator)
+	}
+	if q.SortOperator == "between" {
+		if q.SortValue2 == "" {
+			return q, fmt.Errorf("between needs an upper bound")
+		}
+	} else {
+		q.SortValue2 = ""
+	}
+
+	return q, nil
+}
+
+func (m QueryFormModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render("🔑 Query by Key"))
+	b.WriteString("\n\n")
+
+	b.WriteString(fmt.Sprintf("%s =   %s\n", styles.KeyStyle.Render(m.keys.PartitionKey), m.partitionInput.View()))
+	if m.keys.SortKey != "" {
+		b.WriteString(fmt.Sprintf("%s op: %s\n", styles.KeyStyle.Render(m.keys.SortKey), m.operatorInput.View()))
+		b.WriteString(fmt.Sprintf("Value:    %s\n", m.sortInput.View()))
+		b.WriteString(fmt.Sprintf("And:      %s\n", m.sortInput2.View()))
+	}
+
+	b.WriteString("\n")
+	order := "Ascending"
+	if !m.scanForward {
+		order = "Descending"
+	}
+	b.WriteString(fmt.Sprintf("Order: %s\n", styles.SelectedStyle.Render(order)))
+
+	if m.err != nil {
+		b.WriteString("\n")
+		b.WriteString(styles.ErrorStyle.Render(m.err.Error()))
+		b.WriteString("\n")
+	}
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("Active item filters are applied on top of the query results"))
+	b.WriteString("\n")
+	b.WriteString(
+		helpStyle.Render(
+			"Tab: Next field • Ctrl+O: Toggle order • Enter: Run query • Ctrl+X: Back to scan • Esc: Cancel",
+		),
+	)
+
+	return b.String()
+}
@@ -0,0 +1,634 @@
+package dynamo
+
+import (
+	"cirrus/internal/messages"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// diffKind says on which side of a table diff an item differs
+type diffKind int
+
+const (
+	diffOnlyLeft diffKind = iota
+	diffOnlyRight
+	diffChanged
+)
+
+// Result filters of the diff view, cycled with tab; diffFilterAll shows every kind
+const diffFilterAll = -1
+
+// diffEntry is one item that differs, by the DynamoDB JSON of its key
+type diffEntry struct {
+	kind diffKind
+	key  string
+}
+
+// tableDiff is the result of comparing two sides by primary key. Only keys
+// are kept; items are read again when an entry is opened.
+type tableDiff struct {
+	entries []diffEntry
+	left    int // Items read on each side
+	right   int
+	same    int
+}
+
+// count returns how many entries are of the given kind
+func (d *tableDiff) count(kind diffKind) int {
+	n := 0
+	for _, e := range d.entries {
+		if e.kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// diffSource is one side of a table diff: a table, or a snapshot file in
+// any format the import reads (backups and exports included)
+type diffSource struct {
+	name string
+	keys TableKeySchema
+	open func() (pager itemPager, close func(), err error)
+	find func(ctx context.Context, key map[string]types.AttributeValue) (map[string]types.AttributeValue, error) // nil if missing
+}
+
+type diffTablesLoadedMsg struct {
+	tables []string
+	keys   TableKeySchema // Of the left table
+	err    error
+}
+
+type diffSourceReadyMsg struct {
+	source diffSource
+	err    error
+}
+
+type diffProgressMsg struct {
+	left  int
+	right int
+}
+
+type diffDoneMsg struct {
+	result    *tableDiff
+	cancelled bool
+	err       error
+}
+
+type diffDetailLoadedMsg struct {
+	entry diffEntry
+	left  map[string]types.AttributeValue
+	right map[string]types.AttributeValue
+	err   error
+}
+
+// tableDiffSource reads a whole table with Scan
+func tableDiffSource(client *dynamodb.Client, tableName string, keys TableKeySchema) diffSource {
+	return diffSource{
+		name: tableName,
+		keys: keys,
+		open: func() (itemPager, func(), error) {
+			var startKey map[string]types.AttributeValue
+			pager := func(ctx context.Context) ([]map[string]types.AttributeValue, bool, error) {
+				items, lastKey, err := readPage(client, readParams{TableName: tableName, Keys: keys}, startKey)
+				if err != nil {
+					return nil, false, err
+				}
+				startKey = lastKey
+				return items, len(lastKey) > 0, nil
+			}
+			return pager, func() {}, nil
+		},
+		find: func(ctx context.Context, key map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+			result, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+				TableName:      aws.String(tableName),
+				Key:            itemKey(key, keys),
+				ConsistentRead: aws.Bool(true),
+			})
+			if err != nil || len(result.Item) == 0 {
+				return nil, err
+			}
+			return result.Item, nil
+		},
+	}
+}
+
+// fileDiffSource reads a snapshot file, whose items are keyed like the table it is compared with
+func fileDiffSource(path string, keys TableKeySchema) diffSource {
+	source := diffSource{
+		name: path,
+		keys: keys,
+		open: func() (itemPager, func(), error) {
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			reader, err := openImportReader(file)
+			if err != nil {
+				file.Close()
+				return nil, nil, err
+			}
+			return importPager(reader, keys), func() { file.Close() }, nil
+		},
+	}
+
+	// Snapshot files are read again until the key turns up
+	source.find = func(ctx context.Context, key map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+		pager, closeSource, err := source.open()
+		if err != nil {
+			return nil, err
+		}
+		defer closeSource()
+
+		want := keyString(key, keys)
+		for {
+			items, more, err := pager(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				if keyString(item, keys) == want {
+					return item, nil
+				}
+			}
+			if !more {
+				return nil, nil
+			}
+		}
+	}
+	return source
+}
+
+// normalizeSets sorts set members, so sets that only differ in order compare equal
+func normalizeSets(av types.AttributeValue) types.AttributeValue {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberSS:
+		values := append([]string{}, v.Value...)
+		sort.Strings(values)
+		return &types.AttributeValueMemberSS{Value: values}
+	case *types.AttributeValueMemberNS:
+		values := append([]string{}, v.Value...)
+		sort.Strings(values)
+		return &types.AttributeValueMemberNS{Value: values}
+	case *types.AttributeValueMemberBS:
+		values := append([][]byte{}, v.Value...)
+		sort.Slice(values, func(i, j int) bool { return string(values[i]) < string(values[j]) })
+		return &types.AttributeValueMemberBS{Value: values}
+	case *types.AttributeValueMemberL:
+		values := make([]types.AttributeValue, len(v.Value))
+		for i, child := range v.Value {
+			values[i] = normalizeSets(child)
+		}
+		return &types.AttributeValueMemberL{Value: values}
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: normalizeItem(v.Value)}
+	}
+	return av
+}
+
+func normalizeItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	out := make(map[string]types.AttributeValue, len(item))
+	for name, av := range item {
+		out[name] = normalizeSets(av)
+	}
+	return out
+}
+
+// itemHash fingerprints an item; map keys are sorted by encoding/json
+func itemHash(item map[string]types.AttributeValue) ([32]byte, error) {
+	data, err := json.Marshal(attributeValueToDynamoJSON(&types.AttributeValueMemberM{Value: normalizeItem(item)}))
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// sideItem renames the right side's key attributes to the left side's names
+func sideItem(item map[string]types.AttributeValue, from, to TableKeySchema) map[string]types.AttributeValue {
+	if from.PartitionKey == to.PartitionKey && from.SortKey == to.SortKey {
+		return item
+	}
+	return copyPlan{sourceKeys: from, targetKeys: to}.transform(item)
+}
+
+// hashSide reads every page of a side and fingerprints its items by left-side key
+func hashSide(
+	ctx context.Context,
+	source diffSource,
+	keys TableKeySchema,
+	read *atomic.Int64,
+) (map[string][32]byte, error) {
+	pager, closeSource, err := source.open()
+	if err != nil {
+		return nil, err
+	}
+	defer closeSource()
+
+	hashes := make(map[string][32]byte)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		items, more, err := pager(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source.name, err)
+		}
+		for _, item := range items {
+			item = sideItem(item, source.keys, keys)
+			hash, err := itemHash(item)
+			if err != nil {
+				return nil, err
+			}
+			hashes[keyString(item, keys)] = hash
+		}
+		read.Add(int64(len(items)))
+
+		if !more {
+			return hashes, nil
+		}
+	}
+}
+
+// runTableDiff reads both sides at once and compares them by key. Only keys
+// and fingerprints are held, so large tables don't need two copies in memory.
+func runTableDiff(ctx context.Context, left, right diffSource, updates chan<- tea.Msg) {
+	defer close(updates)
+
+	var leftRead, rightRead atomic.Int64
+	var leftHashes, rightHashes map[string][32]byte
+	var leftErr, rightErr error
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if leftHashes, leftErr = hashSide(runCtx, left, left.keys, &leftRead); leftErr != nil {
+			cancel()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if rightHashes, rightErr = hashSide(runCtx, right, left.keys, &rightRead); rightErr != nil {
+			cancel()
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for running := true; running; {
+		select {
+		case <-ticker.C:
+			// Drop the update if the UI hasn't taken the previous one yet
+			select {
+			case updates <- diffProgressMsg{left: int(leftRead.Load()), right: int(rightRead.Load())}:
+			default:
+			}
+		case <-done:
+			running = false
+		}
+	}
+
+	if ctx.Err() != nil {
+		updates <- diffDoneMsg{cancelled: true}
+		return
+	}
+	for _, err := range []error{leftErr, rightErr} {
+		if err != nil && err != context.Canceled {
+			updates <- diffDoneMsg{err: err}
+			return
+		}
+	}
+
+	result := &tableDiff{left: len(leftHashes), right: len(rightHashes)}
+	for key, hash := range leftHashes {
+		rightHash, ok := rightHashes[key]
+		switch {
+		case !ok:
+			result.entries = append(result.entries, diffEntry{kind: diffOnlyLeft, key: key})
+		case rightHash != hash:
+			result.entries = append(result.entries, diffEntry{kind: diffChanged, key: key})
+		default:
+			result.same++
+		}
+	}
+	for key := range rightHashes {
+		if _, ok := leftHashes[key]; !ok {
+			result.entries = append(result.entries, diffEntry{kind: diffOnlyRight, key: key})
+		}
+	}
+	sort.Slice(result.entries, func(i, j int) bool { return result.entries[i].key < result.entries[j].key })
+
+	updates <- diffDoneMsg{result: result}
+}
+
+// findItem reads one item of a side by its left-side key; nil means it isn't there
+func findItem(ctx context.Context, source diffSource, keys TableKeySchema, key string) (map[string]types.AttributeValue, error) {
+	keyItem, _, err := decodeItem([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := source.find(ctx, sideItem(keyItem, keys, source.keys))
+	if err != nil || item == nil {
+		return nil, err
+	}
+	return sideItem(item, source.keys, keys), nil
+}
+
+// startTableDiff picks the table under the cursor as the left side and lists candidates for the right
+func (m Model) startTableDiff() (tea.Model, tea.Cmd) {
+	if len(m.tables) == 0 {
+		return m, nil
+	}
+	m.selectedTable = m.tables[m.selectedIdx]
+	m.state = stateLoading
+	return m, m.loadDiffTables(m.selectedTable)
+}
+
+// loadDiffTables describes the left table and lists the tables it can be compared with
+func (m Model) loadDiffTables(tableName string) tea.Cmd {
+	client, cfg := m.client, m.config
+	return func() tea.Msg {
+		result, err := client.DescribeTable(context.TODO(), &dynamodb.DescribeTableInput{
+			TableName: aws.String(tableName),
+		})
+		if err != nil {
+			return diffTablesLoadedMsg{err: err}
+		}
+
+		tables, err := listAllEnvTables(client, cfg)
+		return diffTablesLoadedMsg{
+			tables: tables,
+			keys:   keySchemaFromDescription(result.Table.KeySchema, result.Table.AttributeDefinitions),
+			err:    err,
+		}
+	}
+}
+
+func (m Model) handleDiffTablesLoaded(msg diffTablesLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.state = stateTableList
+		return m, nil
+	}
+
+	m.tableKeys[m.selectedTable] = msg.keys
+	m.diffTables = msg.tables
+	m.diffCursor = 0
+	m.diffFileInput = textinput.New()
+	m.diffFileInput.Placeholder = "Snapshot file (.jsonl, .json or .csv)"
+	m.diffFileInput.Width = 60
+	m.state = stateDiffPicker
+	return m, nil
+}
+
+func (m Model) updateDiffPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// The last entry is the snapshot file input
+	fileEntry := len(m.diffTables)
+
+	if m.diffCursor == fileEntry && m.diffFileInput.Focused() {
+		switch msg.String() {
+		case "esc", "up":
+			m.diffFileInput.Blur()
+			if msg.String() == "up" && m.diffCursor > 0 {
+				m.diffCursor--
+			}
+			return m, nil
+		case "enter":
+			path, err := expandHome(strings.TrimSpace(m.diffFileInput.Value()))
+			if err != nil || path == "" {
+				return m, nil
+			}
+			return m.runDiffAgainst(fileDiffSource(path, m.tableKeys[m.selectedTable]))
+		}
+
+		var cmd tea.Cmd
+		m.diffFileInput, cmd = m.diffFileInput.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.state = stateTableList
+
+	case "up", "k":
+		if m.diffCursor > 0 {
+			m.diffCursor--
+		}
+
+	case "down", "j":
+		if m.diffCursor < fileEntry {
+			m.diffCursor++
+		}
+
+	case "enter":
+		if m.diffCursor == fileEntry {
+			m.diffFileInput.Focus()
+			return m, textinput.Blink
+		}
+		m.state = stateLoading
+		return m, m.loadDiffTable(m.diffTables[m.diffCursor])
+	}
+
+	return m, nil
+}
+
+// loadDiffTable describes the right-side table so items can be matched by key
+func (m Model) loadDiffTable(tableName string) tea.Cmd {
+	client := m.client
+	return func() tea.Msg {
+		result, err := client.DescribeTable(context.TODO(), &dynamodb.DescribeTableInput{
+			TableName: aws.String(tableName),
+		})
+		if err != nil {
+			return diffSourceReadyMsg{err: err}
+		}
+		keys := keySchemaFromDescription(result.Table.KeySchema, result.Table.AttributeDefinitions)
+		return diffSourceReadyMsg{source: tableDiffSource(client, tableName, keys)}
+	}
+}
+
+func (m Model) handleDiffSourceReady(msg diffSourceReadyMsg) (tea.Model, tea.Cmd) {
+	m.state = stateDiffPicker
+	if msg.err != nil {
+		return m, messages.ShowToast(msg.err.Error(), messages.ToastError)
+	}
+	return m.runDiffAgainst(msg.source)
+}
+
+// runDiffAgainst compares the selected table with the given right side in the background
+func (m Model) runDiffAgainst(right diffSource) (tea.Model, tea.Cmd) {
+	left := tableDiffSource(m.client, m.selectedTable, m.tableKeys[m.selectedTable])
+	if err := checkKeysCompatible(left.keys, right.keys); err != nil {
+		return m, messages.ShowToast(fmt.Sprintf("Can't compare with %s: %v", right.name, err), messages.ToastError)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan tea.Msg, 1)
+
+	m.diffLeft = left
+	m.diffRight = right
+	m.diffCancel = cancel
+	m.diffUpdates = updates
+	m.diffProgress = diffProgressMsg{}
+	m.diffResult = nil
+	m.state = stateDiffRunning
+
+	return m, tea.Batch(
+		func() tea.Msg {
+			runTableDiff(ctx, left, right, updates)
+			return nil
+		},
+		waitForUpdate(updates),
+	)
+}
+
+func (m Model) updateDiffRunning(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "x":
+		if m.diffCancel != nil {
+			m.diffCancel()
+			m.diffCancel = nil
+		}
+	}
+	return m, nil
+}
+
+func (m Model) handleDiffDone(msg diffDoneMsg) (tea.Model, tea.Cmd) {
+	m.diffCancel = nil
+	m.diffUpdates = nil
+
+	switch {
+	case msg.cancelled:
+		m.state = stateDiffPicker
+		return m, messages.ShowToast("Comparison cancelled", messages.ToastWarning)
+	case msg.err != nil:
+		m.err = msg.err
+		m.state = stateDiffPicker
+		return m, nil
+	}
+
+	m.diffResult = msg.result
+	m.diffFilter = diffFilterAll
+	m.diffResultCursor = 0
+	m.state = stateDiffResults
+	return m, nil
+}
+
+// visibleDiffEntries returns the entries that pass the current filter
+func (m Model) visibleDiffEntries() []diffEntry {
+	if m.diffFilter == diffFilterAll {
+		return m.diffResult.entries
+	}
+
+	var entries []diffEntry
+	for _, e := range m.diffResult.entries {
+		if e.kind == diffKind(m.diffFilter) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func (m Model) updateDiffResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	entries := m.visibleDiffEntries()
+
+	switch msg.String() {
+	case "esc", "q":
+		m.diffResult = nil
+		m.state = stateDiffPicker
+
+	case "tab":
+		// All, only left, only right, changed
+		m.diffFilter++
+		if m.diffFilter > int(diffChanged) {
+			m.diffFilter = diffFilterAll
+		}
+		m.diffResultCursor = 0
+
+	case "up", "k":
+		if m.diffResultCursor > 0 {
+			m.diffResultCursor--
+		}
+
+	case "down", "j":
+		if m.diffResultCursor < len(entries)-1 {
+			m.diffResultCursor++
+		}
+
+	case "pgup":
+		m.diffResultCursor = max(m.diffResultCursor-10, 0)
+
+	case "pgdown":
+		m.diffResultCursor = max(min(m.diffResultCursor+10, len(entries)-1), 0)
+
+	case "enter":
+		if m.diffResultCursor < len(entries) {
+			m.state = stateLoading
+			return m, m.loadDiffDetail(entries[m.diffResultCursor])
+		}
+	}
+
+	return m, nil
+}
+
+// loadDiffDetail reads both versions of an entry for the attribute-level diff
+func (m Model) loadDiffDetail(entry diffEntry) tea.Cmd {
+	left, right := m.diffLeft, m.diffRight
+	keys := left.keys
+
+	return func() tea.Msg {
+		msg := diffDetailLoadedMsg{entry: entry}
+		if msg.left, msg.err = findItem(context.TODO(), left, keys, entry.key); msg.err != nil {
+			return msg
+		}
+		msg.right, msg.err = findItem(context.TODO(), right, keys, entry.key)
+		return msg
+	}
+}
+
+func (m Model) handleDiffDetailLoaded(msg diffDetailLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.state = stateDiffResults
+		return m, messages.ShowToast(fmt.Sprintf("Failed to read item: %v", msg.err), messages.ToastError)
+	}
+
+	m.diffDetailEntry = msg.entry
+	m.diffDetail = diffItems(normalizeItem(msg.left), normalizeItem(msg.right))
+	m.state = stateDiffDetail
+	return m, nil
+}
+
+func (m Model) updateDiffDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "enter":
+		m.diffDetail = nil
+		m.state = stateDiffResults
+	}
+	return m, nil
+}
@@ -0,0 +1,138 @@
+package dynamo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tableInfo gathers a table's metadata without reading any items. The optional
+// parts are left empty with an error when the caller may not read them.
+type tableInfo struct {
+	table   *types.TableDescription
+	keys    TableKeySchema
+	indexes []TableIndex
+
+	ttl    *types.TimeToLiveDescription
+	ttlErr error
+
+	pitr    *types.PointInTimeRecoveryDescription
+	pitrErr error
+
+	tags    []types.Tag
+	tagsErr error
+}
+
+type tableInfoLoadedMsg struct {
+	tableName string
+	info      *tableInfo
+	err       error
+}
+
+// loadTableInfo describes a table and reads its TTL, point-in-time recovery and tags
+func (m Model) loadTableInfo(tableName string) tea.Cmd {
+	client := m.client
+	return func() tea.Msg {
+		ctx := context.TODO()
+
+		result, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(tableName),
+		})
+		if err != nil {
+			return tableInfoLoadedMsg{tableName: tableName, err: err}
+		}
+
+		info := &tableInfo{
+			table:   result.Table,
+			keys:    keySchemaFromDescription(result.Table.KeySchema, result.Table.AttributeDefinitions),
+			indexes: indexesFromDescription(result.Table),
+		}
+
+		if ttl, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+			TableName: aws.String(tableName),
+		}); err != nil {
+			info.ttlErr = err
+		} else {
+			info.ttl = ttl.TimeToLiveDescription
+		}
+
+		if backups, err := client.DescribeContinuousBackups(ctx, &dynamodb.DescribeContinuousBackupsInput{
+			TableName: aws.String(tableName),
+		}); err != nil {
+			info.pitrErr = err
+		} else if backups.ContinuousBackupsDescription != nil {
+			info.pitr = backups.ContinuousBackupsDescription.PointInTimeRecoveryDescription
+		}
+
+		var nextToken *string
+		for {
+			tags, err := client.ListTagsOfResource(ctx, &dynamodb.ListTagsOfResourceInput{
+				ResourceArn: result.Table.TableArn,
+				NextToken:   nextToken,
+			})
+			if err != nil {
+				info.tagsErr = err
+				break
+			}
+			info.tags = append(info.tags, tags.Tags...)
+			if nextToken = tags.NextToken; nextToken == nil {
+				break
+			}
+		}
+		sort.Slice(info.tags, func(i, j int) bool {
+			return aws.ToString(info.tags[i].Key) < aws.ToString(info.tags[j].Key)
+		})
+
+		return tableInfoLoadedMsg{tableName: tableName, info: info}
+	}
+}
+
+func (m Model) handleTableInfoLoaded(msg tableInfoLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		m.state = stateTableList
+		return m, nil
+	}
+
+	m.tableInfo = msg.info
+	m.state = stateTableInfo
+	return m, nil
+}
+
+func (m Model) updateTableInfo(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "i":
+		m.tableInfo = nil
+		m.state = stateTableList
+	case "r":
+		m.state = stateLoading
+		return m, m.loadTableInfo(m.selectedTable)
+	}
+	return m, nil
+}
+
+// formatCapacity describes a table's or index's billing and throughput
+func formatCapacity(billing *types.BillingModeSummary, throughput *types.ProvisionedThroughputDescription) string {
+	if billing != nil && billing.BillingMode == types.BillingModePayPerRequest {
+		return "On-demand"
+	}
+	if throughput == nil {
+		return "Provisioned"
+	}
+	return fmt.Sprintf("Provisioned: %d RCU / %d WCU",
+		aws.ToInt64(throughput.ReadCapacityUnits), aws.ToInt64(throughput.WriteCapacityUnits))
+}
+
+// formatTime renders an optional timestamp in local time
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05 MST")
+}
@@ -6,6 +6,7 @@ import (
 	"cirrus/internal/services/dynamo/filter"
 	"log"
 
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -30,26 +31,91 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tablesLoadedMsg:
 		return m.handleTablesLoaded(msg)
 
-	case deleteCompleteMsg:
+	case batchProgressMsg:
+		m.batchProgress = msg
+		return m, waitForUpdate(m.batchUpdates)
 
-		m.state = stateTableList
-		return m, func() tea.Msg {
-			return messages.ToastMsg{
-				Message: "items deleted",
-				Level:   messages.ToastSuccess,
-			}
-		}
+	case batchDoneMsg:
+		return m.handleBatchDone(msg)
+
+	case exportProgressMsg:
+		m.exportWritten = msg.written
+		return m, waitForUpdate(m.exportUpdates)
+
+	case exportDoneMsg:
+		return m.handleExportDone(msg)
+
+	case tableInfoLoadedMsg:
+		return m.handleTableInfoLoaded(msg)
+
+	case diffTablesLoadedMsg:
+		return m.handleDiffTablesLoaded(msg)
+
+	case diffSourceReadyMsg:
+		return m.handleDiffSourceReady(msg)
+
+	case diffProgressMsg:
+		m.diffProgress = msg
+		return m, waitForUpdate(m.diffUpdates)
+
+	case diffDoneMsg:
+		return m.handleDiffDone(msg)
+
+	case diffDetailLoadedMsg:
+		return m.handleDiffDetailLoaded(msg)
+
+	case copyTargetsLoadedMsg:
+		return m.handleCopyTargetsLoaded(msg)
+
+	case copyTargetKeysLoadedMsg:
+		return m.handleCopyTargetKeysLoaded(msg)
+
+	case importCheckedMsg:
+		return m.handleImportChecked(msg)
+
+	case ExportSubmittedMsg:
+		return m.startExport(msg.Options)
+
+	case backupsLoadedMsg:
+		return m.handleBackupsLoaded(msg)
+
+	case matchingKeysLoadedMsg:
+		return m.handleMatchingKeysLoaded(msg)
 
 	case tableKeysLoadedMsg:
 		return m.handleTableKeysLoaded(msg)
 
+	case itemsCountedMsg:
+		return m.handleItemsCounted(msg)
+
 	case itemsLoadedMsg:
 		// Ignore if we're already in delete confirm or deleting
-		if m.state == stateDeleteConfirm || m.state == stateDeleting {
+		if m.state == stateDeleteConfirm || m.state == stateBatchRunning {
 			return m, nil
 		}
 		return m.handleItemsLoaded(msg)
 
+	case partiqlResultMsg:
+		return m.handlePartiQLResult(msg)
+
+	case itemReloadedMsg:
+		return m.handleItemReloaded(msg)
+
+	case itemSavedMsg:
+		return m.handleItemSaved(msg)
+
+	case itemDeletedMsg:
+		return m.handleItemDeleted(msg)
+
+	case itemsRestoredMsg:
+		return m.handleItemsRestored(msg)
+
+	case QuerySubmittedMsg:
+		query := msg.Query
+		m.activeQuery = &query
+		m.state = stateLoading
+		return m, m.loadItems(m.activeFilters)
+
 	case ColumnFilterSavedMsg:
 		// Save the column preferences
 		m.config.SetTableColumns(m.selectedTable, msg.Columns)
@@ -63,7 +129,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		if keys, ok := m.tableKeys[m.selectedTable]; ok {
+		if _, ok := m.tableKeys[m.selectedTable]; ok {
+			keys := m.activeKeys()
 			m.itemTable, _ = BuildItemTable(ItemTableParams{
 				PartitionKey:    keys.PartitionKey,
 				SortKey:         keys.SortKey,
@@ -110,10 +177,107 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.updateDeleteConfirm(msg)
 	}
 
+	if m.state == stateBatchRunning {
+		return m.updateBatchRunning(msg)
+	}
+
+	if m.state == stateBackupList {
+		return m.updateBackupList(msg)
+	}
+
+	if m.state == stateExportForm {
+		return m.updateExportForm(msg)
+	}
+
+	if m.state == stateExporting {
+		return m.updateExporting(msg)
+	}
+
+	if m.state == stateImportForm {
+		return m.updateImportForm(msg)
+	}
+
+	if m.state == stateImportReview {
+		return m.updateImportReview(msg)
+	}
+
+	if m.state == stateCopyTargetPicker {
+		return m.updateCopyTargetPicker(msg)
+	}
+
+	if m.state == stateCopyOptions {
+		return m.updateCopyOptions(msg)
+	}
+
+	if m.state == stateCopyReport {
+		return m.updateCopyReport(msg)
+	}
+
+	if m.state == stateTableInfo {
+		return m.updateTableInfo(msg)
+	}
+
+	if m.state == stateDiffPicker {
+		return m.updateDiffPicker(msg)
+	}
+
+	if m.state == stateDiffRunning {
+		return m.updateDiffRunning(msg)
+	}
+
+	if m.state == stateDiffResults {
+		return m.updateDiffResults(msg)
+	}
+
+	if m.state == stateDiffDetail {
+		return m.updateDiffDetail(msg)
+	}
+
 	if m.state == stateItemFilter {
 		return m.updateItemFilter(msg)
 	}
 
+	if m.state == stateQueryForm {
+		return m.updateQueryForm(msg)
+	}
+
+	if m.state == stateIndexPicker {
+		return m.updateIndexPicker(msg)
+	}
+
+	if m.state == statePartiQL && m.err == nil {
+		return m.updatePartiQL(msg)
+	}
+
+	if m.state == stateItemEditor && m.err == nil {
+		return m.updateItemEditor(msg)
+	}
+
+	if m.state == stateItemSaveConfirm {
+		return m.updateItemSaveConfirm(msg)
+	}
+
+	if m.state == stateItemDeleteConfirm {
+		return m.updateItemDeleteConfirm(msg)
+	}
+
+	if m.state == stateItemDetail {
+		switch msg.String() {
+		case "d":
+			if m.selectedIdx < len(m.items) {
+				return m.confirmDeleteItems(m.items[m.selectedIdx : m.selectedIdx+1])
+			}
+			return m, nil
+		case "e":
+			if m.selectedIdx < len(m.items) {
+				return m.startItemEdit(m.items[m.selectedIdx])
+			}
+			return m, nil
+		case "N":
+			return m.openItemEditor(nil)
+		}
+	}
+
 	switch msg.String() {
 	case "q":
 		if m.state != stateTableList {
@@ -142,16 +306,34 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "e":
-		// Empty table - load all items first
+		// Empty table - load the keys, then count the items
 		if len(m.tables) > 0 {
 			m.selectedTable = m.tables[m.selectedIdx]
 			m.loadingForDelete = true // Set flag
 			m.state = stateLoading
+			return m, m.loadTableKeys(m.selectedTable)
+		}
 
-			return m, tea.Batch(
-				m.loadTableKeys(m.selectedTable),
-				m.loadItems(nil),
-			)
+	case "b":
+		// Backups of the table under the cursor
+		if m.state == stateTableList && len(m.tables) > 0 {
+			m.selectedTable = m.tables[m.selectedIdx]
+			m.state = stateLoading
+			return m, m.loadBackups(m.selectedTable)
+		}
+
+	case "i":
+		// Metadata of the table under the cursor, without scanning it
+		if m.state == stateTableList && len(m.tables) > 0 {
+			m.selectedTable = m.tables[m.selectedIdx]
+			m.state = stateLoading
+			return m, m.loadTableInfo(m.selectedTable)
+		}
+
+	case "c":
+		// Compare the table under the cursor with another table or a snapshot
+		if m.state == stateTableList {
+			return m.startTableDiff()
 		}
 
 	case "down", "j":
@@ -172,21 +354,106 @@ func (m Model) handleItemListInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		savedFilters := m.config.GetFilterConditions(m.selectedTable)
 		// Copy active filters to editor
-		m.itemFilter.Conditions = append([]filter.FilterCondition{}, savedFilters...)
+		m.itemFilter.SetConditions(append([]filter.FilterCondition{}, savedFilters...))
+		m.itemFilter.SetColumns(m.allColumns)
 		m.state = stateItemFilter
 		return m, nil
 
+	case "Q":
+		// Switch to (or edit) a key-condition query
+		m.queryForm = NewQueryFormModel(m.activeKeys(), m.activeQuery)
+		m.state = stateQueryForm
+		return m, nil
+
+	case "i":
+		// Pick the base table or a secondary index to read from
+		m.indexCursor = 0
+		for i, idx := range m.tableIndexes[m.selectedTable] {
+			if idx.Name == m.selectedIndex {
+				m.indexCursor = i + 1
+			}
+		}
+		m.state = stateIndexPicker
+		return m, nil
+
 	case "c":
 		savedCols := m.config.GetTableColumns(m.selectedTable)
 		m.columnFilter = NewColumnFilterModel(m.allColumns, savedCols)
 		m.state = stateColumnFilter
 		return m, nil
 
+	case "P":
+		return m.openPartiQLConsole()
+
+	case "N":
+		return m.openItemEditor(nil)
+
+	case "E":
+		return m.openExportForm()
+
+	case "I":
+		return m.openImportForm()
+
+	case "C":
+		return m.startCopy()
+
+	case " ":
+		return m.toggleMark()
+
+	case "delete":
+		// Delete the marked rows, or the one under the cursor
+		if marked := m.markedRows(); len(marked) > 0 {
+			return m.confirmDeleteItems(marked)
+		}
+		cursor := m.itemTable.Cursor()
+		if cursor >= 0 && cursor < len(m.items) {
+			return m.confirmDeleteItems(m.items[cursor : cursor+1])
+		}
+		return m, nil
+
+	case "D":
+		// Delete everything the current filters match, across all pages
+		return m.startDeleteMatching()
+
+	case "U":
+		if len(m.undoBuffer) > 0 {
+			m.state = stateLoading
+			return m, m.restoreItems()
+		}
+		return m, nil
+
 	case "r":
 		// Refresh with current filters
 		m.state = stateLoading
+		if m.partiqlStatement != "" {
+			return m, m.executeStatement(m.partiqlStatement, nil, false)
+		}
 		return m, m.loadItems(m.activeFilters)
 
+	case "n":
+		// Load the next page
+		if m.partiqlNextToken != nil {
+			m.state = stateLoading
+			return m, m.executeStatement(m.partiqlStatement, m.partiqlNextToken, true)
+		}
+		if m.lastEvaluatedKey != nil {
+			m.state = stateLoading
+			return m, m.loadNextPage()
+		}
+		return m, nil
+
+	case "a":
+		// Load every remaining page
+		if m.partiqlNextToken != nil {
+			m.state = stateLoading
+			return m, m.executeRemainingStatement()
+		}
+		if m.lastEvaluatedKey != nil {
+			m.state = stateLoading
+			return m, m.loadRemainingPages()
+		}
+		return m, nil
+
 	case "enter":
 		cursor := m.itemTable.Cursor()
 		log.Printf("cursor %d", cursor)
@@ -215,6 +482,14 @@ func (m Model) handleBack() (tea.Model, tea.Cmd) {
 		m.state = stateTableList
 		m.selectedTable = ""
 		m.items = nil
+		m.lastEvaluatedKey = nil
+		m.activeQuery = nil
+		m.selectedIndex = ""
+		m.partiqlStatement = ""
+		m.partiqlNextToken = nil
+		m.columnTypes = nil
+		m.markedItems = nil
+		m.undoBuffer = nil
 		m.selectedIdx = 0
 		return m, nil
 
@@ -236,32 +511,61 @@ func (m Model) handleItemsLoaded(msg itemsLoadedMsg) (tea.Model, tea.Cmd) {
 		m.state = stateTableList
 	} else {
 
-		m.items = msg.items
-		// Check if we were loading for deletion
-		if m.loadingForDelete {
-			m.loadingForDelete = false // Reset flag
-
-			if len(m.items) == 0 {
-				m.state = stateTableList
-				return m, messages.ShowToast("Table is already empty", messages.ToastInfo)
-			}
-
-			m.confirmInput = newDeleteConfirmInput()
-			m.state = stateDeleteConfirm
-			return m, nil
+		if msg.appendPage {
+			m.items = append(m.items, msg.items...)
+		} else {
+			m.items = msg.items
+			m.markedItems = nil
+		}
+		m.lastEvaluatedKey = msg.lastEvaluatedKey
+		m.partiqlNextToken = msg.nextToken
+		m.columnTypes = sampleColumnTypes(msg.items, m.columnTypes)
+
+		cursor := 0
+		if msg.appendPage {
+			cursor = m.itemTable.Cursor()
+		} else {
+			m.selectedIdx = 0
 		}
-
-		m.selectedIdx = 0
 		m.state = stateItemList
+		m.rebuildItemTable(cursor)
 
-		// Build table
-		if keys, ok := m.tableKeys[m.selectedTable]; ok {
-			m.itemTable, m.allColumns = BuildItemTable(ItemTableParams{
-				PartitionKey: keys.PartitionKey, SortKey: keys.SortKey, Items: m.items,
-			})
-		}
+	}
+	return m, nil
+}
+
+// rebuildItemTable rebuilds the item table from m.items and moves the cursor to the given row
+func (m *Model) rebuildItemTable(cursor int) {
+	if _, ok := m.tableKeys[m.selectedTable]; !ok {
+		return
+	}
+	keys := m.activeKeys()
+	tableKeys := m.tableKeys[m.selectedTable]
+	m.itemTable, m.allColumns = BuildItemTable(ItemTableParams{
+		PartitionKey: keys.PartitionKey, SortKey: keys.SortKey, Items: m.items,
+		IsMarked: func(item map[string]types.AttributeValue) bool {
+			return m.markedItems[keyString(item, tableKeys)]
+		},
+	})
+	m.itemTable.SetCursor(cursor)
+}
 
+// handleItemsCounted asks for confirmation before emptying a table that has items
+func (m Model) handleItemsCounted(msg itemsCountedMsg) (tea.Model, tea.Cmd) {
+	m.loadingForDelete = false
+	if msg.err != nil {
+		m.err = msg.err
+		m.state = stateTableList
+		return m, nil
 	}
+	if msg.count == 0 {
+		m.state = stateTableList
+		return m, messages.ShowToast("Table is already empty", messages.ToastInfo)
+	}
+
+	m.emptyCount = msg.count
+	m.confirmInput = newDeleteConfirmInput()
+	m.state = stateDeleteConfirm
 	return m, nil
 }
 
@@ -284,6 +588,11 @@ func (m Model) handleTableKeysLoaded(msg tableKeysLoadedMsg) (tea.Model, tea.Cmd
 		m.state = stateTableList
 	} else {
 		m.tableKeys[msg.tableName] = msg.keys
+		m.tableIndexes[msg.tableName] = msg.indexes
+		// Empty Table only counts the items
+		if m.loadingForDelete {
+			return m, m.countItems(msg.tableName)
+		}
 		return m, m.loadItems(m.activeFilters)
 	}
 	return m, nil
@@ -325,6 +634,12 @@ func (m Model) updateColumnFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.columnFilter.Height = msg.Height
 
 	case tea.KeyMsg:
+		if m.columnFilter.IsEditing() {
+			var cmd tea.Cmd
+			m.columnFilter, cmd = m.columnFilter.Update(msg)
+			return m, cmd
+		}
+
 		switch msg.String() {
 		case "esc":
 			m.state = stateItemList
@@ -357,6 +672,12 @@ func (m Model) updateItemFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case "ctrl+s":
+			// Text mode has to parse before there is anything to apply
+			var err error
+			if m.itemFilter, err = m.itemFilter.Apply(); err != nil {
+				return m, nil
+			}
+
 			// Apply filters and reload from DynamoDB
 			m.config.SetFilterConditions(m.selectedTable, m.itemFilter.Conditions)
 
@@ -391,3 +712,63 @@ func (m Model) updateItemFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.itemFilter, cmd = m.itemFilter.Update(msg)
 	return m, cmd
 }
+
+func (m Model) updateQueryForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.state = stateItemList
+			return m, nil
+
+		case "ctrl+x":
+			// Drop the query and go back to scanning the table
+			m.activeQuery = nil
+			m.state = stateLoading
+			return m, m.loadItems(m.activeFilters)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.queryForm, cmd = m.queryForm.Update(msg)
+	return m, cmd
+}
+
+func (m Model) updateIndexPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	indexes := m.tableIndexes[m.selectedTable]
+
+	switch msg.String() {
+	case "esc", "q":
+		m.state = stateItemList
+		return m, nil
+
+	case "up", "k":
+		if m.indexCursor > 0 {
+			m.indexCursor--
+		}
+
+	case "down", "j":
+		// Entry 0 is the base table
+		if m.indexCursor < len(indexes) {
+			m.indexCursor++
+		}
+
+	case "enter":
+		selected := ""
+		if m.indexCursor > 0 {
+			selected = indexes[m.indexCursor-1].Name
+		}
+		if selected == m.selectedIndex {
+			m.state = stateItemList
+			return m, nil
+		}
+
+		// Key conditions don't carry over between key schemas
+		m.selectedIndex = selected
+		m.activeQuery = nil
+		m.state = stateLoading
+		return m, m.loadItems(m.activeFilters)
+	}
+
+	return m, nil
+}
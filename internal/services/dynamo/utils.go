@@ -1,8 +1,11 @@
 package dynamo
 
 import (
+	"cirrus/internal/services/dynamo/filter"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -122,3 +125,101 @@ func truncateString(s string, maxLen int) string {
 	}
 	return s[:maxLen-3] + "..."
 }
+
+// keyAttributeValue converts user input into an AttributeValue of the key's scalar type
+func keyAttributeValue(t types.ScalarAttributeType, value string) (types.AttributeValue, error) {
+	switch t {
+	case types.ScalarAttributeTypeN:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return &types.AttributeValueMemberN{Value: value}, nil
+
+	case types.ScalarAttributeTypeB:
+		b, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("binary keys must be base64 encoded")
+		}
+		return &types.AttributeValueMemberB{Value: b}, nil
+
+	default:
+		return &types.AttributeValueMemberS{Value: value}, nil
+	}
+}
+
+// filterValueType maps an AttributeValue to the filter value type used to compare against it
+func filterValueType(av types.AttributeValue) string {
+	switch av.(type) {
+	case *types.AttributeValueMemberN, *types.AttributeValueMemberNS:
+		return filter.ValueTypeNumber
+	case *types.AttributeValueMemberBOOL:
+		return filter.ValueTypeBool
+	case *types.AttributeValueMemberNULL:
+		return filter.ValueTypeNull
+	default:
+		return filter.ValueTypeString
+	}
+}
+
+// sampleColumnTypes records the filter value type of each column seen in the first items
+func sampleColumnTypes(
+	items []map[string]types.AttributeValue,
+	columnTypes map[string]string,
+) map[string]string {
+	if columnTypes == nil {
+		columnTypes = make(map[string]string)
+	}
+
+	sampleSize := min(50, len(items))
+	for i := 0; i < sampleSize; i++ {
+		for col, av := range items[i] {
+			// Null values say nothing about the column's real type
+			if _, ok := columnTypes[col]; ok && filterValueType(av) == filter.ValueTypeNull {
+				continue
+			}
+			columnTypes[col] = filterValueType(av)
+		}
+	}
+
+	return columnTypes
+}
+
+// samplePathType returns the filter value type of a nested path in the first items
+func samplePathType(items []map[string]types.AttributeValue, path string) string {
+	sampleSize := min(50, len(items))
+	for i := 0; i < sampleSize; i++ {
+		if av, ok := resolveAttributePath(items[i], path); ok && filterValueType(av) != filter.ValueTypeNull {
+			return filterValueType(av)
+		}
+	}
+	return ""
+}
+
+// itemKey extracts the primary key attributes of an item
+func itemKey(item map[string]types.AttributeValue, keys TableKeySchema) map[string]types.AttributeValue {
+	key := map[string]types.AttributeValue{keys.PartitionKey: item[keys.PartitionKey]}
+	if keys.SortKey != "" {
+		key[keys.SortKey] = item[keys.SortKey]
+	}
+	return key
+}
+
+// sameKey reports whether two items have the same primary key
+func sameKey(a, b map[string]types.AttributeValue, keys TableKeySchema) bool {
+	for name, av := range itemKey(a, keys) {
+		other, ok := b[name]
+		if av == nil || !ok || !attributeValuesEqual(av, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// keyString identifies an item by its primary key, for use as a map key
+func keyString(item map[string]types.AttributeValue, keys TableKeySchema) string {
+	data, err := encodeItem(itemKey(item, keys), formatDynamoJSON, false)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
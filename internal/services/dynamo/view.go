@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -30,10 +32,48 @@ func (m Model) View() string {
 		content = m.columnFilter.View()
 	case stateItemFilter:
 		content = m.itemFilter.View()
+	case stateQueryForm:
+		content = m.queryForm.View()
+	case stateIndexPicker:
+		content = m.renderIndexPicker()
+	case statePartiQL:
+		content = m.renderPartiQL()
+	case stateItemEditor:
+		content = m.renderItemEditor()
+	case stateItemSaveConfirm:
+		content = m.renderItemSaveConfirm()
+	case stateItemDeleteConfirm:
+		return m.renderItemDeleteConfirm()
 	case stateDeleteConfirm:
 		return m.renderDeleteConfirm()
-	case stateDeleting:
-		return m.renderDeleting()
+	case stateBatchRunning:
+		return m.renderBatchProgress()
+	case stateBackupList:
+		content = m.renderBackupList()
+	case stateExportForm:
+		content = m.exportForm.View()
+	case stateExporting:
+		return m.renderExportProgress()
+	case stateImportForm:
+		content = m.renderImportForm()
+	case stateImportReview:
+		content = m.renderImportReview()
+	case stateCopyTargetPicker:
+		content = m.renderCopyTargetPicker()
+	case stateCopyOptions:
+		content = m.renderCopyOptions()
+	case stateCopyReport:
+		content = m.renderCopyReport()
+	case stateTableInfo:
+		content = m.renderTableInfo()
+	case stateDiffPicker:
+		content = m.renderDiffPicker()
+	case stateDiffRunning:
+		return m.renderDiffProgress()
+	case stateDiffResults:
+		content = m.renderDiffResults()
+	case stateDiffDetail:
+		content = m.renderDiffDetail()
 	}
 	return content
 }
@@ -82,7 +122,7 @@ func (m Model) renderTableList() string {
 	b.WriteString("\n")
 	b.WriteString(
 		styles.HelpStyle.Render(
-			"↑/↓: Navigate • Enter: Select • r: Refresh • e: Empty Table • q: Back to Menu",
+			"↑/↓: Navigate • Enter: Select • r: Refresh • e: Empty Table • i: Info • b: Backups • c: Compare • q: Back to Menu",
 		),
 	)
 
@@ -92,9 +132,37 @@ func (m Model) renderTableList() string {
 func (m Model) renderItemListTable() string {
 	var b strings.Builder
 
-	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("Items in %s", m.selectedTable)))
+	title := fmt.Sprintf("Items in %s", m.selectedTable)
+	if m.selectedIndex != "" {
+		title += fmt.Sprintf(" (index: %s)", m.selectedIndex)
+	}
+	b.WriteString(styles.TitleStyle.Render(title))
 	b.WriteString("\n")
 
+	if m.partiqlStatement != "" {
+		partiqlBadgeStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("0")).
+			Background(lipgloss.Color("205")).
+			Bold(true).
+			Padding(0, 1)
+
+		badge := partiqlBadgeStyle.Render("📝 PartiQL")
+		detail := styles.HelpStyle.Render(strings.Join(strings.Fields(m.partiqlStatement), " "))
+		b.WriteString(badge + " " + detail)
+		b.WriteString("\n")
+	} else if m.activeQuery != nil {
+		queryBadgeStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("0")).
+			Background(lipgloss.Color("12")).
+			Bold(true).
+			Padding(0, 1)
+
+		badge := queryBadgeStyle.Render("🔑 Query")
+		detail := styles.HelpStyle.Render(m.activeQuery.String(m.activeKeys()))
+		b.WriteString(badge + " " + detail)
+		b.WriteString("\n")
+	}
+
 	// Show active filters badge
 	if len(m.activeFilters) > 0 {
 		filterBadgeStyle := lipgloss.NewStyle().
@@ -105,7 +173,7 @@ func (m Model) renderItemListTable() string {
 
 		var filters []string
 		for _, f := range m.activeFilters {
-			filters = append(filters, fmt.Sprintf("%s %s %s", f.Column, f.Operator, f.Value))
+			filters = append(filters, f.String())
 		}
 
 		badge := filterBadgeStyle.Render(fmt.Sprintf("🔍 %d filter(s) active", len(m.activeFilters)))
@@ -114,17 +182,34 @@ func (m Model) renderItemListTable() string {
 		b.WriteString("\n")
 	}
 
-	b.WriteString(fmt.Sprintf("Total items: %d\n", len(m.items)))
+	total := fmt.Sprintf("Total items: %d (complete)", len(m.items))
+	if m.hasMorePages() {
+		total = fmt.Sprintf("Total items: %d (partial - more pages available)", len(m.items))
+	}
+	if marked := len(m.markedRows()); marked > 0 {
+		total += fmt.Sprintf(" • %d marked", marked)
+	}
+	b.WriteString(total + "\n")
 	b.WriteString("\n")
 	b.WriteString(m.itemTable.View())
 	b.WriteString("\n\n")
 
-	help := "↑/↓: Navigate • Enter: View Details • Delete: Delete Item • c: Column Filter • "
+	help := "↑/↓: Navigate • Enter: View Details • N: New Item • Space: Mark • Delete: Delete Item(s) • "
+	if len(m.undoBuffer) > 0 {
+		help += "U: Undo Delete • "
+	}
+	if len(m.activeFilters) > 0 || m.activeQuery != nil {
+		help += "D: Delete Matching • "
+	}
+	help += "c: Column Filter • Q: Query by Key • i: Index • P: PartiQL • E: Export • I: Import • C: Copy • "
 	if len(m.activeFilters) > 0 {
-		help += "f: Edit Filters "
+		help += "f: Edit Filters • "
 	} else {
 		help += "f: Add Filters • "
 	}
+	if m.hasMorePages() {
+		help += "n: Next Page • a: Load All • "
+	}
 	help += "r: Refresh • q: Back"
 
 	b.WriteString(styles.HelpStyle.Render(help))
@@ -132,6 +217,182 @@ func (m Model) renderItemListTable() string {
 	return b.String()
 }
 
+func (m Model) renderPartiQL() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("📝 PartiQL - %s", m.selectedTable)))
+	b.WriteString("\n\n")
+	b.WriteString(m.partiqlInput.View())
+	b.WriteString("\n")
+
+	if history := m.config.GetPartiQLHistory(m.selectedTable); len(history) > 0 {
+		position := "new"
+		if m.partiqlHistoryIdx >= 0 {
+			position = fmt.Sprintf("%d/%d", m.partiqlHistoryIdx+1, len(history))
+		}
+		b.WriteString(styles.TypeStyle.Render(fmt.Sprintf("History: %s", position)))
+		b.WriteString("\n")
+	}
+
+	if m.partiqlConfirm {
+		warningStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("196")).
+			Padding(1, 0)
+
+		b.WriteString(warningStyle.Render("⚠️  This statement modifies data. Run it? (y/n)"))
+		return b.String()
+	}
+
+	b.WriteString(
+		styles.HelpStyle.Render(
+			"Ctrl+S: Run • Ctrl+P/Ctrl+N: History • Ctrl+X: Back to Scan • Esc: Back",
+		),
+	)
+
+	return b.String()
+}
+
+func (m Model) renderItemEditor() string {
+	var b strings.Builder
+
+	title := fmt.Sprintf("✏️  Edit Item - %s", m.selectedTable)
+	if m.editOriginal == nil {
+		title = fmt.Sprintf("✏️  New Item - %s", m.selectedTable)
+	}
+	b.WriteString(styles.TitleStyle.Render(title))
+	b.WriteString("\n")
+
+	format := "DynamoDB JSON"
+	if m.editorFormat == formatPlainJSON {
+		format = "JSON (types are inferred; sets and binary become lists and strings)"
+	}
+	b.WriteString(styles.TypeStyle.Render("Format: " + format))
+	b.WriteString("\n\n")
+	b.WriteString(m.editorInput.View())
+	b.WriteString("\n")
+
+	if m.editErr != nil {
+		b.WriteString(styles.ErrorStyle.Render("✗ " + m.editErr.Error()))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(
+		styles.HelpStyle.Render(
+			"Ctrl+S: Review & Save • Ctrl+O: Toggle DynamoDB JSON/JSON • Esc: Cancel",
+		),
+	)
+
+	return b.String()
+}
+
+// renderAttributeDiff renders attribute changes as +/-/~ lines
+func (m Model) renderAttributeDiff(diffs []attributeDiff) string {
+	var b strings.Builder
+
+	addedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	removedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	changedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	width := max(m.Width-10, 40)
+
+	for _, d := range diffs {
+		switch {
+		case d.Old == nil:
+			b.WriteString(addedStyle.Render(truncateString(fmt.Sprintf("+ %s: %s", d.Name, compactDynamoJSON(d.New)), width)))
+		case d.New == nil:
+			b.WriteString(removedStyle.Render(truncateString(fmt.Sprintf("- %s: %s", d.Name, compactDynamoJSON(d.Old)), width)))
+		default:
+			b.WriteString(changedStyle.Render(fmt.Sprintf("~ %s", d.Name)))
+			b.WriteString("\n")
+			b.WriteString(removedStyle.Render(truncateString("    - "+compactDynamoJSON(d.Old), width)))
+			b.WriteString("\n")
+			b.WriteString(addedStyle.Render(truncateString("    + "+compactDynamoJSON(d.New), width)))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func (m Model) renderItemSaveConfirm() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("Review changes - %s", m.selectedTable)))
+	b.WriteString("\n\n")
+
+	b.WriteString(m.renderAttributeDiff(m.editDiff))
+	b.WriteString("\n")
+
+	changedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	if m.keyChanged() {
+		b.WriteString(changedStyle.Render("⚠️  The primary key changed: the item moves to the new key and the original is deleted"))
+		b.WriteString("\n")
+	}
+
+	check := "[ ]"
+	if m.editConditional {
+		check = "[x]"
+	}
+	condition := "Only save if the item is unchanged since it was loaded"
+	if m.editOriginal == nil {
+		condition = "Only save if no item with this key exists"
+	}
+	b.WriteString(fmt.Sprintf("%s %s\n", check, condition))
+
+	b.WriteString(styles.HelpStyle.Render("y: Save • c: Toggle Condition • n/Esc: Back to Editor"))
+
+	return b.String()
+}
+
+func (m Model) renderIndexPicker() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("Indexes of %s", m.selectedTable)))
+	b.WriteString("\n\n")
+
+	entries := []string{
+		fmt.Sprintf("%-30s %s", "(base table)", formatKeySchema(m.tableKeys[m.selectedTable])),
+	}
+	for _, idx := range m.tableIndexes[m.selectedTable] {
+		kind := "LSI"
+		if idx.Global {
+			kind = "GSI"
+		}
+		entries = append(entries, fmt.Sprintf("%-30s %s  %s  %s",
+			idx.Name,
+			styles.TypeStyle.Render(kind),
+			formatKeySchema(idx.Keys),
+			styles.TypeStyle.Render("projection: "+idx.Projection),
+		))
+	}
+
+	for i, entry := range entries {
+		if i == m.indexCursor {
+			b.WriteString(styles.SelectedStyle.Render("▶ " + entry))
+		} else {
+			b.WriteString("  " + entry)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(entries) == 1 {
+		b.WriteString("\nThis table has no secondary indexes\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("↑/↓: Navigate • Enter: Read from index • Esc: Back"))
+
+	return b.String()
+}
+
+func formatKeySchema(keys TableKeySchema) string {
+	s := fmt.Sprintf("PK: %s", keys.PartitionKey)
+	if keys.SortKey != "" {
+		s += fmt.Sprintf(", SK: %s", keys.SortKey)
+	}
+	return s
+}
+
 func (m Model) renderItemDetail() string {
 	if m.selectedIdx >= len(m.items) {
 		return "Invalid item selection"
@@ -171,7 +432,7 @@ func (m Model) renderItemDetail() string {
 		b.WriteString("\n\n")
 	}
 
-	b.WriteString(styles.HelpStyle.Render("d: Delete • Esc: Back to List"))
+	b.WriteString(styles.HelpStyle.Render("e: Edit • N: New Item • d: Delete • Esc: Back to List"))
 
 	return styles.BoxStyle.Render(b.String())
 }
@@ -277,22 +538,53 @@ func (m Model) renderDeleteConfirm() string {
 		Foreground(lipgloss.Color("196")).
 		Padding(1, 0)
 
-	b.WriteString(warningStyle.Render("⚠️  EMPTY TABLE"))
-	b.WriteString("\n\n")
-
 	infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-
-	b.WriteString(
-		infoStyle.Render(fmt.Sprintf("You are about to delete ALL %d items from:", len(m.items))),
-	)
-	b.WriteString("\n\n")
-
 	tableStyle := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("12"))
 
-	b.WriteString(tableStyle.Render(m.selectedTable))
-	b.WriteString("\n\n")
+	if m.deleteMatching {
+		b.WriteString(warningStyle.Render("⚠️  DELETE MATCHING ITEMS"))
+		b.WriteString("\n\n")
+		b.WriteString(
+			infoStyle.Render(fmt.Sprintf("You are about to delete %d items matching the current filters from:", len(m.deleteKeys))),
+		)
+		b.WriteString("\n\n")
+		b.WriteString(tableStyle.Render(m.selectedTable))
+		b.WriteString("\n\n")
+
+		var conditions []string
+		if m.activeQuery != nil {
+			conditions = append(conditions, m.activeQuery.String(m.activeKeys()))
+		}
+		for _, f := range m.activeFilters {
+			conditions = append(conditions, f.String())
+		}
+		b.WriteString(styles.HelpStyle.Render("Where " + strings.Join(conditions, " AND ")))
+		b.WriteString("\n\n")
+
+		keys := m.tableKeys[m.selectedTable]
+		const sampleSize = 10
+		b.WriteString(infoStyle.Render("Sample of the affected keys:"))
+		b.WriteString("\n")
+		for _, key := range m.deleteKeys[:min(sampleSize, len(m.deleteKeys))] {
+			b.WriteString("  " + formatItemKey(key, keys) + "\n")
+		}
+		if len(m.deleteKeys) > sampleSize {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("  … and %d more", len(m.deleteKeys)-sampleSize)))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	} else {
+		b.WriteString(warningStyle.Render("⚠️  EMPTY TABLE"))
+		b.WriteString("\n\n")
+		b.WriteString(
+			infoStyle.Render(fmt.Sprintf("You are about to delete ALL %d items from:", m.emptyCount)),
+		)
+		b.WriteString("\n\n")
+		b.WriteString(tableStyle.Render(m.selectedTable))
+		b.WriteString("\n\n")
+	}
 
 	b.WriteString(warningStyle.Render("THIS ACTION CANNOT BE UNDONE!"))
 	b.WriteString("\n\n")
@@ -315,7 +607,54 @@ func (m Model) renderDeleteConfirm() string {
 	return b.String()
 }
 
-func (m Model) renderDeleting() string {
+func (m Model) renderItemDeleteConfirm() string {
+	var b strings.Builder
+
+	warningStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("196")).
+		Padding(1, 0)
+
+	b.WriteString(warningStyle.Render(fmt.Sprintf("⚠️  DELETE %d ITEM(S)", len(m.deleteTargets))))
+	b.WriteString("\n\n")
+
+	infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	b.WriteString(infoStyle.Render(fmt.Sprintf("From %s:", m.selectedTable)))
+	b.WriteString("\n\n")
+
+	keys := m.tableKeys[m.selectedTable]
+	const shown = 10
+	for i, item := range m.deleteTargets {
+		if i == shown {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("  … and %d more", len(m.deleteTargets)-shown)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString("  " + formatItemKey(item, keys) + "\n")
+	}
+	b.WriteString("\n")
+
+	check := "[ ]"
+	if m.keepUndo {
+		check = "[x]"
+	}
+	b.WriteString(fmt.Sprintf("%s Keep the deleted items so they can be restored with U\n\n", check))
+
+	b.WriteString(styles.HelpStyle.Render("y: Delete • u: Toggle Undo • n/Esc: Cancel"))
+
+	return b.String()
+}
+
+// formatItemKey renders an item's primary key as pk=value, sk=value
+func formatItemKey(item map[string]types.AttributeValue, keys TableKeySchema) string {
+	s := fmt.Sprintf("%s=%s", keys.PartitionKey, formatAttributeValueCompact(item[keys.PartitionKey]))
+	if keys.SortKey != "" {
+		s += fmt.Sprintf(", %s=%s", keys.SortKey, formatAttributeValueCompact(item[keys.SortKey]))
+	}
+	return s
+}
+
+func (m Model) renderBatchProgress() string {
 	var b strings.Builder
 
 	titleStyle := lipgloss.NewStyle().
@@ -323,17 +662,530 @@ func (m Model) renderDeleting() string {
 		Foreground(lipgloss.Color("12")).
 		Padding(1, 0)
 
-	b.WriteString(titleStyle.Render("🗑️  Deleting Items..."))
+	title, action, source := "🗑️  Deleting Items...", "Deleting", "from"
+	switch m.batchJob.kind {
+	case jobRestore:
+		title, action, source = "♻️  Restoring Backup...", "Restoring", "into"
+	case jobImport:
+		title, action, source = "📥 Importing Items...", "Importing", "into"
+	case jobCopy:
+		title, action, source = "📋 Copying Items...", "Copying", "into"
+	}
+	b.WriteString(titleStyle.Render(title))
 	b.WriteString("\n\n")
 
 	infoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 
+	p := m.batchProgress
+	total := max(m.batchJob.total, p.scanned)
+	if p.scanDone {
+		total = p.scanned
+	}
+
 	b.WriteString(
-		infoStyle.Render(fmt.Sprintf("Deleting %d items from %s", m.deleteTotal, m.selectedTable)),
+		infoStyle.Render(fmt.Sprintf("%s %d items %s %s", action, total, source, m.batchJob.tableName)),
 	)
 	b.WriteString("\n\n")
 
-	b.WriteString("⏳ Please wait...")
+	const barWidth = 40
+	filled := 0
+	if total > 0 {
+		filled = min(p.written*barWidth/total, barWidth)
+	}
+	b.WriteString("[" + strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled) + "]")
+	b.WriteString(fmt.Sprintf(" %d/%d", p.written, total))
+	if !p.scanDone {
+		b.WriteString(infoStyle.Render(" (still reading)"))
+	}
+	b.WriteString("\n\n")
+
+	elapsed := time.Since(m.batchStarted)
+	throughput := 0.0
+	if elapsed > 0 {
+		throughput = float64(p.written) / elapsed.Seconds()
+	}
+	b.WriteString(infoStyle.Render(fmt.Sprintf(
+		"Retries: %d • %.0f items/s • %s elapsed",
+		p.retries, throughput, elapsed.Truncate(time.Second),
+	)))
+	b.WriteString("\n\n")
+
+	if m.batchCancelled {
+		b.WriteString("⏳ Cancelling, waiting for in-flight batches...")
+	} else {
+		b.WriteString(styles.HelpStyle.Render("Esc/x: Cancel"))
+	}
+
+	return b.String()
+}
+
+func (m Model) renderExportProgress() string {
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("12")).
+		Padding(1, 0)
+
+	b.WriteString(titleStyle.Render("💾 Exporting Items..."))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("Written %d items from %s", m.exportWritten, m.selectedTable))
+	b.WriteString("\n\n")
+	b.WriteString(styles.HelpStyle.Render("Esc/x: Cancel"))
 
 	return b.String()
 }
+
+func (m Model) renderImportForm() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("📥 Import into %s", m.selectedTable)))
+	b.WriteString("\n\n")
+	b.WriteString("JSON Lines and JSON arrays may hold plain or DynamoDB JSON items.\n")
+	b.WriteString("CSV headers take type hints like age:N (S, N, BOOL, NULL, B, SS, NS, BS, L, M).\n\n")
+	b.WriteString("File: " + m.importInput.View())
+	b.WriteString("\n\n")
+	b.WriteString(styles.HelpStyle.Render("Enter: Dry Run • Esc: Cancel"))
+
+	return b.String()
+}
+
+func (m Model) renderImportReview() string {
+	var b strings.Builder
+	check := m.importCheck
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("📥 Import into %s", m.selectedTable)))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("File:    %s (%s)\n", check.path, check.format))
+	b.WriteString(fmt.Sprintf("Records: %d\n\n", check.records))
+
+	switch {
+	case check.invalid > 0:
+		b.WriteString(styles.ErrorStyle.Render(fmt.Sprintf("✗ %d invalid record(s), nothing will be written", check.invalid)))
+		b.WriteString("\n\n")
+		for _, problem := range check.problems {
+			b.WriteString("  " + problem + "\n")
+		}
+		if more := check.invalid - len(check.problems); more > 0 {
+			b.WriteString(fmt.Sprintf("  ... and %d more\n", more))
+		}
+		b.WriteString("\n")
+		b.WriteString(styles.HelpStyle.Render("Esc: Back"))
+
+	case check.records == 0:
+		b.WriteString("The file has no records.\n\n")
+		b.WriteString(styles.HelpStyle.Render("Esc: Back"))
+
+	default:
+		okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+		b.WriteString(okStyle.Render("✓ Every record has the table's key attributes and a unique key"))
+		b.WriteString("\n\n")
+		b.WriteString("Existing items with the same key will be overwritten.\n\n")
+		b.WriteString(styles.HelpStyle.Render("y/Enter: Import • n/Esc: Cancel"))
+	}
+
+	return b.String()
+}
+
+// copySourceDescription says which items a copy takes
+func (m Model) copySourceDescription() string {
+	if len(m.copyItems) > 0 {
+		return fmt.Sprintf("%d marked item(s) of %s", len(m.copyItems), m.selectedTable)
+	}
+	if m.partiqlStatement != "" || m.activeQuery != nil || len(m.activeFilters) > 0 {
+		return fmt.Sprintf("every item of %s matching the current results", m.selectedTable)
+	}
+	return fmt.Sprintf("every item of %s", m.selectedTable)
+}
+
+func (m Model) renderCopyTargetPicker() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render("📋 Copy Items"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("Copy %s into:\n\n", m.copySourceDescription()))
+
+	for i, table := range m.copyTargets {
+		entry := table
+		if table == m.selectedTable {
+			entry += styles.TypeStyle.Render("  (this table)")
+		}
+		if i == m.copyCursor {
+			b.WriteString(styles.SelectedStyle.Render("▶ " + entry))
+		} else {
+			b.WriteString("  " + entry)
+		}
+		b.WriteString("\n")
+	}
+	if len(m.copyTargets) == 0 {
+		b.WriteString("No tables found\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("↑/↓: Navigate • Enter: Select Target • Esc: Cancel"))
+
+	return b.String()
+}
+
+func (m Model) renderCopyOptions() string {
+	var b strings.Builder
+	plan := m.copyPlan
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("📋 Copy into %s", plan.target)))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("Source: %s\n", m.copySourceDescription()))
+	b.WriteString(fmt.Sprintf("Keys:   %s → %s\n\n", formatKeySchema(plan.sourceKeys), formatKeySchema(plan.targetKeys)))
+
+	b.WriteString("Rewrite key prefixes (optional, string keys only):\n")
+	b.WriteString("  From: " + m.copyFromInput.View() + "\n")
+	b.WriteString("  To:   " + m.copyToInput.View() + "\n\n")
+
+	overwrite := "[ ] Overwrite items that already exist (they are backed up first)"
+	if plan.overwrite {
+		overwrite = "[✓] Overwrite items that already exist (they are backed up first)"
+	}
+	b.WriteString(overwrite + "\n")
+	if !plan.overwrite {
+		b.WriteString(styles.TypeStyle.Render("    Existing items are skipped and listed when the copy finishes"))
+		b.WriteString("\n")
+	}
+
+	if m.copyErr != nil {
+		b.WriteString("\n")
+		b.WriteString(styles.ErrorStyle.Render(m.copyErr.Error()))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("Tab: Switch Field • Ctrl+O: Toggle Overwrite • Enter: Copy • Esc: Back"))
+
+	return b.String()
+}
+
+func (m Model) renderCopyReport() string {
+	var b strings.Builder
+	report := m.copyReport
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("📋 Copied into %s", m.copyPlan.target)))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("%d item(s) written\n", report.written))
+	if report.overwritten > 0 {
+		b.WriteString(fmt.Sprintf("%d existing item(s) overwritten, backup: %s\n", report.overwritten, report.backupPath))
+	}
+	if report.backupErr != nil {
+		b.WriteString(styles.ErrorStyle.Render("The backup is incomplete: " + report.backupErr.Error()))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(styles.ErrorStyle.Render(fmt.Sprintf("%d item(s) skipped because their key already exists in %s:", report.conflicts, m.copyPlan.target)))
+	b.WriteString("\n\n")
+	for _, key := range report.samples {
+		b.WriteString("  " + key + "\n")
+	}
+	if more := report.conflicts - len(report.samples); more > 0 {
+		b.WriteString(fmt.Sprintf("  ... and %d more\n", more))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("Enter/Esc: Back to List"))
+
+	return b.String()
+}
+
+func (m Model) renderTableInfo() string {
+	var b strings.Builder
+	info := m.tableInfo
+	table := info.table
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("ℹ️  %s", m.selectedTable)))
+	b.WriteString("\n\n")
+
+	row := func(label, value string) {
+		b.WriteString(styles.KeyStyle.Render(fmt.Sprintf("%-20s", label)))
+		b.WriteString(value + "\n")
+	}
+	unavailable := func(err error) string {
+		return styles.TypeStyle.Render("unavailable: " + err.Error())
+	}
+
+	row("Status", string(table.TableStatus))
+	row("Created", formatTime(table.CreationDateTime))
+	row("Key", formatKeySchema(info.keys))
+	row("Items", fmt.Sprintf("~%d", aws.ToInt64(table.ItemCount)))
+	row("Size", formatBytes(aws.ToInt64(table.TableSizeBytes)))
+	b.WriteString(styles.TypeStyle.Render("                    Item count and size are refreshed by DynamoDB about every six hours"))
+	b.WriteString("\n")
+	row("Capacity", formatCapacity(table.BillingModeSummary, table.ProvisionedThroughput))
+	if table.TableClassSummary != nil {
+		row("Table class", string(table.TableClassSummary.TableClass))
+	}
+
+	stream := "Disabled"
+	if spec := table.StreamSpecification; spec != nil && aws.ToBool(spec.StreamEnabled) {
+		stream = fmt.Sprintf("Enabled (%s)", spec.StreamViewType)
+	}
+	row("Stream", stream)
+
+	switch {
+	case info.ttlErr != nil:
+		row("TTL", unavailable(info.ttlErr))
+	case info.ttl != nil && info.ttl.AttributeName != nil:
+		row("TTL", fmt.Sprintf("%s (%s)", aws.ToString(info.ttl.AttributeName), info.ttl.TimeToLiveStatus))
+	default:
+		row("TTL", "Disabled")
+	}
+
+	switch {
+	case info.pitrErr != nil:
+		row("Point-in-time rec.", unavailable(info.pitrErr))
+	case info.pitr != nil && info.pitr.PointInTimeRecoveryStatus == types.PointInTimeRecoveryStatusEnabled:
+		row("Point-in-time rec.", fmt.Sprintf("Enabled, restorable %s → %s",
+			formatTime(info.pitr.EarliestRestorableDateTime), formatTime(info.pitr.LatestRestorableDateTime)))
+	default:
+		row("Point-in-time rec.", "Disabled")
+	}
+
+	if table.DeletionProtectionEnabled != nil {
+		row("Deletion protection", fmt.Sprintf("%t", aws.ToBool(table.DeletionProtectionEnabled)))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.KeyStyle.Render("Indexes"))
+	b.WriteString("\n")
+	if len(info.indexes) == 0 {
+		b.WriteString("  none\n")
+	}
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		b.WriteString(fmt.Sprintf("  %-28s GSI  %s  %s  %s  %s\n",
+			aws.ToString(gsi.IndexName),
+			formatKeySchema(keySchemaFromDescription(gsi.KeySchema, table.AttributeDefinitions)),
+			styles.TypeStyle.Render("projection: "+projectionFromDescription(gsi.Projection)),
+			formatCapacity(table.BillingModeSummary, gsi.ProvisionedThroughput),
+			gsi.IndexStatus,
+		))
+	}
+	for _, lsi := range table.LocalSecondaryIndexes {
+		b.WriteString(fmt.Sprintf("  %-28s LSI  %s  %s\n",
+			aws.ToString(lsi.IndexName),
+			formatKeySchema(keySchemaFromDescription(lsi.KeySchema, table.AttributeDefinitions)),
+			styles.TypeStyle.Render("projection: "+projectionFromDescription(lsi.Projection)),
+		))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.KeyStyle.Render("Tags"))
+	b.WriteString("\n")
+	switch {
+	case info.tagsErr != nil:
+		b.WriteString("  " + unavailable(info.tagsErr) + "\n")
+	case len(info.tags) == 0:
+		b.WriteString("  none\n")
+	}
+	for _, tag := range info.tags {
+		b.WriteString(fmt.Sprintf("  %s = %s\n", aws.ToString(tag.Key), aws.ToString(tag.Value)))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("r: Refresh • Esc: Back"))
+
+	return b.String()
+}
+
+func (m Model) renderDiffPicker() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("⚖️  Compare %s", m.selectedTable)))
+	b.WriteString("\n\n")
+	b.WriteString("Compare with:\n\n")
+
+	for i, table := range m.diffTables {
+		entry := table
+		if table == m.selectedTable {
+			entry += styles.TypeStyle.Render("  (this table)")
+		}
+		if i == m.diffCursor {
+			b.WriteString(styles.SelectedStyle.Render("▶ " + entry))
+		} else {
+			b.WriteString("  " + entry)
+		}
+		b.WriteString("\n")
+	}
+
+	entry := "Snapshot file: " + m.diffFileInput.View()
+	if m.diffCursor == len(m.diffTables) {
+		b.WriteString(styles.SelectedStyle.Render("▶ ") + entry)
+	} else {
+		b.WriteString("  " + entry)
+	}
+	b.WriteString("\n\n")
+
+	help := "↑/↓: Navigate • Enter: Compare • Esc: Back"
+	if m.diffFileInput.Focused() {
+		help = "Enter: Compare with File • Esc: Stop Typing"
+	}
+	b.WriteString(styles.HelpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m Model) renderDiffProgress() string {
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("12")).
+		Padding(1, 0)
+
+	b.WriteString(titleStyle.Render("⚖️  Comparing..."))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("%-8s %s: %d items read\n", "Left", m.diffLeft.name, m.diffProgress.left))
+	b.WriteString(fmt.Sprintf("%-8s %s: %d items read\n", "Right", m.diffRight.name, m.diffProgress.right))
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("Esc/x: Cancel"))
+
+	return b.String()
+}
+
+func (m Model) renderDiffResults() string {
+	var b strings.Builder
+	result := m.diffResult
+
+	b.WriteString(styles.TitleStyle.Render("⚖️  Comparison"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("◀ Left:  %s (%d items)\n", m.diffLeft.name, result.left))
+	b.WriteString(fmt.Sprintf("▶ Right: %s (%d items)\n\n", m.diffRight.name, result.right))
+
+	filters := []struct {
+		label string
+		count int
+	}{
+		{"All", len(result.entries)},
+		{"Only left", result.count(diffOnlyLeft)},
+		{"Only right", result.count(diffOnlyRight)},
+		{"Changed", result.count(diffChanged)},
+	}
+	var tabs []string
+	for i, f := range filters {
+		tab := fmt.Sprintf("%s (%d)", f.label, f.count)
+		if i-1 == m.diffFilter {
+			tab = styles.SelectedStyle.Render("[" + tab + "]")
+		} else {
+			tab = " " + tab + " "
+		}
+		tabs = append(tabs, tab)
+	}
+	b.WriteString(strings.Join(tabs, " "))
+	b.WriteString(styles.TypeStyle.Render(fmt.Sprintf("   %d identical", result.same)))
+	b.WriteString("\n\n")
+
+	entries := m.visibleDiffEntries()
+	if len(entries) == 0 {
+		b.WriteString("No differences\n")
+	}
+
+	// Only the rows around the cursor are rendered
+	height := max(m.Height-14, 5)
+	start := max(min(m.diffResultCursor-height/2, len(entries)-height), 0)
+	end := min(start+height, len(entries))
+
+	markers := map[diffKind]string{diffOnlyLeft: "◀", diffOnlyRight: "▶", diffChanged: "~"}
+	width := max(m.Width-10, 40)
+	for i := start; i < end; i++ {
+		line := truncateString(markers[entries[i].kind]+" "+entries[i].key, width)
+		if i == m.diffResultCursor {
+			b.WriteString(styles.SelectedStyle.Render(line))
+		} else {
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+	if len(entries) > height {
+		b.WriteString(styles.TypeStyle.Render(fmt.Sprintf("%d-%d of %d", start+1, end, len(entries))))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("↑/↓/PgUp/PgDn: Navigate • Tab: Filter • Enter: Attribute Diff • Esc: Back"))
+
+	return b.String()
+}
+
+func (m Model) renderDiffDetail() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render("⚖️  " + m.diffDetailEntry.key))
+	b.WriteString("\n\n")
+
+	switch m.diffDetailEntry.kind {
+	case diffOnlyLeft:
+		b.WriteString(fmt.Sprintf("Only in %s\n\n", m.diffLeft.name))
+	case diffOnlyRight:
+		b.WriteString(fmt.Sprintf("Only in %s\n\n", m.diffRight.name))
+	}
+	b.WriteString(styles.TypeStyle.Render(fmt.Sprintf("- %s   + %s", m.diffLeft.name, m.diffRight.name)))
+	b.WriteString("\n\n")
+
+	if len(m.diffDetail) == 0 {
+		// The item changed again since the comparison ran
+		b.WriteString("Both sides are identical now\n")
+	}
+	b.WriteString(m.renderAttributeDiff(m.diffDetail))
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("Esc: Back to Results"))
+
+	return b.String()
+}
+
+func (m Model) renderBackupList() string {
+	var b strings.Builder
+
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf("Backups of %s", m.selectedTable)))
+	b.WriteString("\n\n")
+
+	if len(m.backups) == 0 {
+		b.WriteString("No backups yet. One is written before every edit and delete.\n")
+	}
+
+	for i, backup := range m.backups {
+		line := fmt.Sprintf("%-50s %10s  %s",
+			backup.Name,
+			formatBytes(backup.Size),
+			backup.ModTime.Format("2006-01-02 15:04:05"),
+		)
+		if i == m.backupCursor {
+			b.WriteString(styles.SelectedStyle.Render("▶ " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	if m.restoring {
+		warningStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("196")).
+			Padding(1, 0)
+
+		b.WriteString(warningStyle.Render(fmt.Sprintf(
+			"⚠️  Restore %s into %s? Items with the same key are overwritten. (y/n)",
+			m.backups[m.backupCursor].Name, m.selectedTable,
+		)))
+		return b.String()
+	}
+
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("↑/↓: Navigate • Enter: Restore • Esc: Back"))
+
+	return b.String()
+}
+
+// formatBytes renders a size as B, KB or MB
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}